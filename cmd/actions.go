@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	"github.com/persys-dev/persysctl/internal/client"
+)
+
+var (
+	agentActionSince    string
+	agentActionUntil    string
+	agentActionOutput   string
+	agentActionSummary  bool
+	agentActionFollow   bool
+	agentActionInterval time.Duration
+)
+
+// followActionLimit is how many of the newest actions each follow poll
+// fetches.
+const followActionLimit = 200
+
+// agentAction is an agent ActionRecord with its timestamps converted.
+type agentAction struct {
+	ID         string    `json:"id,omitempty"`
+	WorkloadID string    `json:"workloadId,omitempty"`
+	Type       string    `json:"actionType"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"startedAt,omitzero"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+	DurationMs float64   `json:"durationMs,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// at is the action's position on a timeline.
+func (a agentAction) at() time.Time {
+	if !a.StartedAt.IsZero() {
+		return a.StartedAt
+	}
+	return a.FinishedAt
+}
+
+// key identifies an action across polls; a status change counts as new.
+func (a agentAction) key() string {
+	id := a.ID
+	if id == "" {
+		id = a.WorkloadID + "|" + a.Type + "|" + a.at().String()
+	}
+	return id + "|" + a.Status
+}
+
+func (a agentAction) failed() bool {
+	return actionStatusFailed(a.Status)
+}
+
+func toAgentAction(r *agentv1.ActionRecord) agentAction {
+	a := agentAction{
+		ID:         r.GetId(),
+		WorkloadID: r.GetWorkloadId(),
+		Type:       r.GetActionType(),
+		Status:     r.GetStatus(),
+		StartedAt:  actionTime(r.GetStartedAt()),
+		FinishedAt: actionTime(r.GetCompletedAt()),
+		Error:      r.GetError(),
+		Message:    r.GetMessage(),
+	}
+	if ms := r.GetDurationMs(); ms > 0 {
+		a.DurationMs = float64(ms)
+	} else if !a.StartedAt.IsZero() && !a.FinishedAt.IsZero() && a.FinishedAt.After(a.StartedAt) {
+		a.DurationMs = round3(float64(a.FinishedAt.Sub(a.StartedAt)) / float64(time.Millisecond))
+	}
+	return a
+}
+
+// actionTime converts an agent timestamp in unix seconds; zero means unset.
+func actionTime(ts int64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(ts, 0).UTC()
+}
+
+// filterActions keeps actions inside [since, until]. Actions without a
+// timestamp cannot be placed in a range; they are dropped and counted.
+func filterActions(actions []agentAction, since, until time.Time) ([]agentAction, int) {
+	if since.IsZero() && until.IsZero() {
+		return actions, 0
+	}
+	out := make([]agentAction, 0, len(actions))
+	untimed := 0
+	for _, a := range actions {
+		at := a.at()
+		if at.IsZero() {
+			untimed++
+			continue
+		}
+		if (!since.IsZero() && at.Before(since)) || (!until.IsZero() && at.After(until)) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, untimed
+}
+
+// filterActionsWarn is filterActions with a warning about dropped records.
+func filterActionsWarn(actions []agentAction, since, until time.Time) []agentAction {
+	out, untimed := filterActions(actions, since, until)
+	if untimed > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: skipped %d action(s) without a timestamp\n", untimed)
+	}
+	return out
+}
+
+// actionTypeSummary aggregates the actions of one type.
+type actionTypeSummary struct {
+	Type        string             `json:"actionType"`
+	Total       int                `json:"total"`
+	Failed      int                `json:"failed"`
+	FailureRate float64            `json:"failureRate"`
+	ByStatus    map[string]int     `json:"byStatus"`
+	AvgMs       float64            `json:"avgDurationMs,omitempty"`
+	DurationMs  map[string]float64 `json:"durationMs,omitempty"`
+}
+
+func summarizeActions(actions []agentAction) []actionTypeSummary {
+	byType := map[string]*actionTypeSummary{}
+	durations := map[string][]time.Duration{}
+	for _, a := range actions {
+		typ := valueOrDash(a.Type)
+		s, ok := byType[typ]
+		if !ok {
+			s = &actionTypeSummary{Type: typ, ByStatus: map[string]int{}}
+			byType[typ] = s
+		}
+		s.Total++
+		s.ByStatus[valueOrDash(a.Status)]++
+		if a.failed() {
+			s.Failed++
+		}
+		if a.DurationMs > 0 {
+			durations[typ] = append(durations[typ], time.Duration(a.DurationMs*float64(time.Millisecond)))
+		}
+	}
+	out := make([]actionTypeSummary, 0, len(byType))
+	for typ, s := range byType {
+		s.FailureRate = round3(float64(s.Failed) / float64(s.Total))
+		if d := durations[typ]; len(d) > 0 {
+			var sum time.Duration
+			for _, v := range d {
+				sum += v
+			}
+			s.AvgMs = round3(float64(sum/time.Duration(len(d))) / float64(time.Millisecond))
+			s.DurationMs = latencySummary(d)
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+// fetchAgentActions lists actions with the server-side filters from the
+// list-actions flags and returns them oldest first.
+func fetchAgentActions(c *client.Client, limit int32, newestFirst bool) ([]agentAction, error) {
+	resp, err := c.AgentListActions(agentActionWorkloadID, agentActionType, agentActionStatus, limit, newestFirst)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]agentAction, 0, len(resp.GetActions()))
+	for _, r := range resp.GetActions() {
+		actions = append(actions, toAgentAction(r))
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].at().Before(actions[j].at()) })
+	return actions, nil
+}
+
+// actionWriter prints actions in one of the list-actions output formats.
+// Table output is line-oriented so it also works while following.
+type actionWriter struct {
+	format string
+	out    io.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+func newActionWriter(format string, out io.Writer) (*actionWriter, error) {
+	w := &actionWriter{format: strings.ToLower(format), out: out}
+	switch w.format {
+	case "table", "json", "ndjson":
+	case "csv":
+		w.csv = csv.NewWriter(out)
+	default:
+		return nil, fmt.Errorf("invalid --output %q (expected proto, table, json, ndjson or csv)", format)
+	}
+	return w, nil
+}
+
+func (w *actionWriter) write(actions []agentAction) error {
+	switch w.format {
+	case "json":
+		data, err := json.MarshalIndent(actions, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w.out, string(data))
+		return err
+	case "ndjson":
+		enc := json.NewEncoder(w.out)
+		for _, a := range actions {
+			if err := enc.Encode(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		if !w.header {
+			w.header = true
+			if err := w.csv.Write([]string{"started_at", "finished_at", "duration_ms", "workload_id", "action_type", "status", "id", "error"}); err != nil {
+				return err
+			}
+		}
+		for _, a := range actions {
+			dur := ""
+			if a.DurationMs > 0 {
+				dur = strconv.FormatFloat(a.DurationMs, 'f', -1, 64)
+			}
+			if err := w.csv.Write([]string{
+				formatActionTime(a.StartedAt, ""), formatActionTime(a.FinishedAt, ""), dur,
+				a.WorkloadID, a.Type, a.Status, a.ID, a.Error,
+			}); err != nil {
+				return err
+			}
+		}
+		w.csv.Flush()
+		return w.csv.Error()
+	default:
+		tw := tabwriter.NewWriter(w.out, 0, 0, 2, ' ', 0)
+		if !w.header {
+			w.header = true
+			_, _ = fmt.Fprintln(tw, "TIME\tWORKLOAD\tTYPE\tSTATUS\tDURATION\tERROR")
+		}
+		for _, a := range actions {
+			dur := "-"
+			if a.DurationMs > 0 {
+				dur = (time.Duration(a.DurationMs * float64(time.Millisecond))).Round(time.Millisecond).String()
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", formatActionTime(a.at(), "-"), valueOrDash(a.WorkloadID),
+				valueOrDash(a.Type), valueOrDash(a.Status), dur, valueOrDash(a.Error))
+		}
+		return tw.Flush()
+	}
+}
+
+func formatActionTime(t time.Time, zero string) string {
+	if t.IsZero() {
+		return zero
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func printActionSummary(rows []actionTypeSummary, format string) error {
+	if strings.EqualFold(format, "json") {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TYPE\tTOTAL\tFAILED\tFAILURE%\tAVG\tP95\tMAX\tSTATUSES")
+	for _, r := range rows {
+		statuses := make([]string, 0, len(r.ByStatus))
+		for s, n := range r.ByStatus {
+			statuses = append(statuses, fmt.Sprintf("%s=%d", s, n))
+		}
+		sort.Strings(statuses)
+		avg, p95, maxDur := "-", "-", "-"
+		if r.DurationMs != nil {
+			avg = fmt.Sprintf("%.0fms", r.AvgMs)
+			p95 = fmt.Sprintf("%.0fms", r.DurationMs["p95"])
+			maxDur = fmt.Sprintf("%.0fms", r.DurationMs["max"])
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%s\t%s\t%s\t%s\n", r.Type, r.Total, r.Failed, r.FailureRate*100,
+			avg, p95, maxDur, strings.Join(statuses, " "))
+	}
+	return tw.Flush()
+}
+
+// runListActions implements the analysis, export and follow modes of
+// agent list-actions.
+func runListActions(c *client.Client) error {
+	since, err := parseSince(agentActionSince)
+	if err != nil {
+		return err
+	}
+	until, err := parseSince(agentActionUntil)
+	if err != nil {
+		return fmt.Errorf("invalid --until %q (expected a duration like 10m or an RFC3339 time)", agentActionUntil)
+	}
+
+	if agentActionFollow && agentActionInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	if agentActionSummary {
+		actions, err := fetchAgentActions(c, agentActionLimit, agentActionNewest)
+		if err != nil {
+			return err
+		}
+		return printActionSummary(summarizeActions(filterActionsWarn(actions, since, until)), agentActionOutput)
+	}
+
+	format := agentActionOutput
+	if strings.EqualFold(format, "proto") {
+		// The raw response cannot be filtered; print the matching records.
+		format = "json"
+	}
+	w, err := newActionWriter(format, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if !agentActionFollow {
+		actions, err := fetchAgentActions(c, agentActionLimit, agentActionNewest)
+		if err != nil {
+			return err
+		}
+		actions = filterActionsWarn(actions, since, until)
+		if agentActionNewest {
+			for i, j := 0, len(actions)-1; i < j; i, j = i+1, j-1 {
+				actions[i], actions[j] = actions[j], actions[i]
+			}
+		}
+		return w.write(actions)
+	}
+
+	if w.format == "json" {
+		w.format = "ndjson"
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	seen := map[string]bool{}
+	warned := false
+	for {
+		actions, err := fetchAgentActions(c, followActionLimit, true)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: list actions: %v\n", err)
+		} else {
+			var fresh []agentAction
+			// Untimed records come back on every poll; warn about them once.
+			matched, untimed := filterActions(actions, since, until)
+			if untimed > 0 && !warned {
+				warned = true
+				_, _ = fmt.Fprintf(os.Stderr, "warning: skipped %d action(s) without a timestamp\n", untimed)
+			}
+			for _, a := range matched {
+				if !seen[a.key()] {
+					seen[a.key()] = true
+					fresh = append(fresh, a)
+				}
+			}
+			if len(fresh) > 0 {
+				if err := w.write(fresh); err != nil {
+					return err
+				}
+			}
+		}
+		if !until.IsZero() && time.Now().After(until) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(agentActionInterval):
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+)
+
+func TestActionTime(t *testing.T) {
+	if got := actionTime(0); !got.IsZero() {
+		t.Errorf("actionTime(0) = %v, want zero", got)
+	}
+	if got := actionTime(-5); !got.IsZero() {
+		t.Errorf("actionTime(-5) = %v, want zero", got)
+	}
+	want := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	if got := actionTime(want.Unix()); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("actionTime = %v, want %v", got, want)
+	}
+}
+
+func TestToAgentAction(t *testing.T) {
+	a := toAgentAction(&agentv1.ActionRecord{
+		Id: "a1", WorkloadId: "web", ActionType: "start", Status: "SUCCEEDED",
+		StartedAt: 100, CompletedAt: 102,
+	})
+	if a.ID != "a1" || a.WorkloadID != "web" || a.Type != "start" || a.DurationMs != 2000 || !a.at().Equal(time.Unix(100, 0)) {
+		t.Fatalf("toAgentAction = %+v", a)
+	}
+	if a := toAgentAction(&agentv1.ActionRecord{DurationMs: 350, StartedAt: 100, CompletedAt: 200}); a.DurationMs != 350 {
+		t.Fatalf("DurationMs = %v, want the reported 350", a.DurationMs)
+	}
+}
+
+func TestFilterActions(t *testing.T) {
+	at := func(sec int64) agentAction {
+		return agentAction{ID: time.Unix(sec, 0).String(), StartedAt: actionTime(sec)}
+	}
+	untimed := agentAction{ID: "untimed"}
+	actions := []agentAction{at(100), untimed, at(200), at(300)}
+
+	got, dropped := filterActions(actions, time.Time{}, time.Time{})
+	if len(got) != 4 || dropped != 0 {
+		t.Fatalf("no range: got %d actions, %d dropped; want all kept", len(got), dropped)
+	}
+
+	got, dropped = filterActions(actions, time.Unix(150, 0), time.Unix(300, 0))
+	if want := []agentAction{at(200), at(300)}; !reflect.DeepEqual(got, want) || dropped != 1 {
+		t.Fatalf("range: got %+v, %d dropped; want %+v, 1 dropped", got, dropped, want)
+	}
+
+	got, _ = filterActions(actions, time.Time{}, time.Unix(100, 0))
+	if want := []agentAction{at(100)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("until only: got %+v, want %+v", got, want)
+	}
+	if actions[1].ID != "untimed" {
+		t.Fatal("filterActions modified its input")
+	}
+}
+
+func TestSummarizeActions(t *testing.T) {
+	actions := []agentAction{
+		{Type: "start", Status: "SUCCEEDED", DurationMs: 100},
+		{Type: "start", Status: "FAILED", DurationMs: 300},
+		{Type: "start", Status: "SUCCEEDED"},
+		{Type: "pull", Status: "ERROR"},
+		{Status: "SUCCEEDED"},
+		{Type: "stop", Status: "SUCCEEDED"},
+	}
+	rows := summarizeActions(actions)
+	var types []string
+	for _, r := range rows {
+		types = append(types, r.Type)
+	}
+	if want := []string{"start", "-", "pull", "stop"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("summary order = %v, want %v", types, want)
+	}
+
+	start := rows[0]
+	if start.Total != 3 || start.Failed != 1 || start.FailureRate != 0.333 || start.AvgMs != 200 {
+		t.Fatalf("start summary = %+v", start)
+	}
+	if !reflect.DeepEqual(start.ByStatus, map[string]int{"SUCCEEDED": 2, "FAILED": 1}) {
+		t.Fatalf("start statuses = %v", start.ByStatus)
+	}
+	if start.DurationMs["max"] != 300 || start.DurationMs["p50"] != 100 {
+		t.Fatalf("start durations = %v", start.DurationMs)
+	}
+
+	pull := rows[2]
+	if pull.Failed != 1 || pull.FailureRate != 1 || pull.DurationMs != nil {
+		t.Fatalf("pull summary = %+v", pull)
+	}
+}
@@ -3,11 +3,14 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
+	"time"
 
 	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -23,11 +26,18 @@ var (
 	agentActionStatus     string
 	agentActionLimit      int32
 	agentActionNewest     bool
+	agentNodeID           string
 )
 
 var agentCmd = &cobra.Command{
 	Use:   "agent",
 	Short: "Standalone compute-agent RPCs",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if agentNodeID == "" {
+			return nil
+		}
+		return useNodeAgent(agentNodeID)
+	},
 }
 
 var agentHealthCmd = &cobra.Command{
@@ -48,12 +58,11 @@ var agentApplyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply workload to standalone compute-agent from a spec file",
 	Run: func(cmd *cobra.Command, args []string) {
-		specData, err := os.ReadFile(agentApplySpecFile)
+		specData, err := readSpecFile(agentApplySpecFile)
 		cobra.CheckErr(err)
 
 		req := &agentv1.ApplyWorkloadRequest{
 			Id:           agentApplyID,
-			RevisionId:   agentApplyRevisionID,
 			DesiredState: desiredState(agentApplyDesired),
 			Spec:         &agentv1.WorkloadSpec{},
 		}
@@ -77,6 +86,17 @@ var agentApplyCmd = &cobra.Command{
 		default:
 			cobra.CheckErr(fmt.Errorf("unsupported --type %q, use container|compose|vm", agentApplyType))
 		}
+		req.RevisionId = revisionFor(agentApplyRevisionID, req.Spec)
+
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		switch mode {
+		case "client":
+			cobra.CheckErr(printProtoAs(req, dryRunOutput))
+			return
+		case "server":
+			cobra.CheckErr(fmt.Errorf("server-side dry-run is only available for scheduler targets; use --dry-run=client"))
+		}
 
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
@@ -135,11 +155,23 @@ var agentDeleteCmd = &cobra.Command{
 var agentListActionsCmd = &cobra.Command{
 	Use:   "list-actions",
 	Short: "List compute-agent action/task history",
+	Long: `List compute-agent action/task history.
+
+--since and --until restrict the timeline (durations like 2h or RFC3339
+times), --summary aggregates by action type with failure rates and
+durations, and --follow tails new actions until interrupted. Use
+-o table|json|ndjson|csv to export; the default proto output prints the raw
+response when no time filter is given.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
 
+		if agentActionSince != "" || agentActionUntil != "" || agentActionSummary || agentActionFollow ||
+			!strings.EqualFold(agentActionOutput, "proto") {
+			cobra.CheckErr(runListActions(c))
+			return
+		}
 		resp, err := c.AgentListActions(agentActionWorkloadID, agentActionType, agentActionStatus, agentActionLimit, agentActionNewest)
 		cobra.CheckErr(err)
 		printProto(resp)
@@ -155,13 +187,17 @@ func init() {
 	agentCmd.AddCommand(agentDeleteCmd)
 	agentCmd.AddCommand(agentListActionsCmd)
 
+	agentCmd.PersistentFlags().StringVar(&agentNodeID, "node", "", "Resolve the agent endpoint of this node through the scheduler instead of --grpc-endpoint")
+
 	agentApplyCmd.Flags().StringVar(&agentApplyID, "id", "", "Workload ID")
 	agentApplyCmd.Flags().StringVar(&agentApplyType, "type", "container", "Workload type: container|compose|vm")
 	agentApplyCmd.Flags().StringVar(&agentApplySpecFile, "spec-file", "", "Path to JSON spec file")
-	agentApplyCmd.Flags().StringVar(&agentApplyRevisionID, "revision", "rev-1", "Workload revision ID")
+	agentApplyCmd.Flags().StringVar(&agentApplyRevisionID, "revision", "", "Workload revision ID (default: content hash of the spec)")
 	agentApplyCmd.Flags().StringVar(&agentApplyDesired, "desired-state", "running", "Desired state: running|stopped")
 	cobra.CheckErr(agentApplyCmd.MarkFlagRequired("id"))
 	cobra.CheckErr(agentApplyCmd.MarkFlagRequired("spec-file"))
+	addSpecRenderFlags(agentApplyCmd)
+	addDryRunFlags(agentApplyCmd)
 
 	agentStatusCmd.Flags().StringVar(&agentStatusID, "id", "", "Workload ID")
 	agentDeleteCmd.Flags().StringVar(&agentDeleteID, "id", "", "Workload ID")
@@ -173,6 +209,12 @@ func init() {
 	agentListActionsCmd.Flags().StringVar(&agentActionStatus, "action-status", "", "Filter by action status")
 	agentListActionsCmd.Flags().Int32Var(&agentActionLimit, "action-limit", 0, "Limit results (0 = all)")
 	agentListActionsCmd.Flags().BoolVar(&agentActionNewest, "newest-first", true, "Sort by newest first")
+	agentListActionsCmd.Flags().StringVar(&agentActionSince, "since", "", "Only actions at or after this time (duration like 1h, or RFC3339)")
+	agentListActionsCmd.Flags().StringVar(&agentActionUntil, "until", "", "Only actions at or before this time (duration like 10m, or RFC3339)")
+	agentListActionsCmd.Flags().StringVarP(&agentActionOutput, "output", "o", "proto", "Output format: proto|table|json|ndjson|csv")
+	agentListActionsCmd.Flags().BoolVar(&agentActionSummary, "summary", false, "Aggregate by action type (table or json)")
+	agentListActionsCmd.Flags().BoolVarP(&agentActionFollow, "follow", "f", false, "Keep polling and print new actions as they appear")
+	agentListActionsCmd.Flags().DurationVar(&agentActionInterval, "interval", 2*time.Second, "Poll interval for --follow")
 }
 
 func desiredState(s string) agentv1.DesiredState {
@@ -183,3 +225,28 @@ func desiredState(s string) agentv1.DesiredState {
 		return agentv1.DesiredState_DESIRED_STATE_RUNNING
 	}
 }
+
+// useNodeAgent looks nodeID up through the configured scheduler (or
+// gateway) and points the gRPC settings at that node's agent, so the agent
+// commands dial it with the same mTLS identity.
+func useNodeAgent(nodeID string) error {
+	cfg := config.GetConfig()
+	if cfg.Transport == "grpc" && cfg.GRPCTarget == "agent" {
+		return fmt.Errorf("--node resolves the agent through the scheduler; use --transport http or --grpc-target scheduler")
+	}
+	c, err := client.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	endpoint, err := c.NodeAgentEndpoint(nodeID)
+	if err != nil {
+		return err
+	}
+	config.Logf("resolved node %s to agent endpoint %s", nodeID, endpoint)
+	viper.Set("transport", "grpc")
+	viper.Set("grpc_target", "agent")
+	viper.Set("grpc_endpoint", endpoint)
+	return nil
+}
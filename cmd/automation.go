@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/persys-dev/persysctl/internal/automation"
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	automationWorkloadID   string
+	automationAction       string
+	automationDesiredState string
+	automationReplicas     int32
+	automationDelta        int32
+	automationReason       string
+	automationPolicyID     string
+	automationPolicyFile   string
+	automationRunNodeID    string
+	automationRunStatus    string
+)
+
+var automationCmd = &cobra.Command{
+	Use:   "automation",
+	Short: "Submit automation suggestions to the scheduler",
+}
+
+var automationSuggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Submit a single automation suggestion for a workload",
+	Run: func(cmd *cobra.Command, args []string) {
+		action := automation.Action{
+			Type:            automationAction,
+			DesiredState:    automationDesiredState,
+			DesiredReplicas: automationReplicas,
+			ReplicaDelta:    automationDelta,
+		}
+		if action.DesiredState != "" {
+			action.DesiredState = normalizeDesiredState(action.DesiredState)
+		}
+		reason := automationReason
+		if reason == "" {
+			reason = "submitted with persysctl"
+		}
+		policy := automation.Policy{ID: automationPolicyID, Name: "persysctl", Action: action, Reason: reason}
+		cobra.CheckErr((&automation.File{Policies: []automation.Policy{policy}}).Validate())
+		suggestion := policy.Suggestion(automationWorkloadID)
+
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		if mode == "server" {
+			cobra.CheckErr(fmt.Errorf("--dry-run=server is not supported for automation suggestions"))
+		}
+		if mode == "client" {
+			cobra.CheckErr(printProtoAs(suggestion, dryRunOutput))
+			return
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		resp, err := c.SubmitAutomationSuggestion(suggestion)
+		cobra.CheckErr(err)
+		printProto(resp)
+	},
+}
+
+var automationRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Evaluate a policy file against current workloads and submit the resulting suggestions",
+	Run: func(cmd *cobra.Command, args []string) {
+		policies, err := automation.LoadFile(automationPolicyFile)
+		cobra.CheckErr(err)
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		if mode == "server" {
+			cobra.CheckErr(fmt.Errorf("--dry-run=server is not supported for automation suggestions"))
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		workloads, err := c.ListWorkloads(automationRunNodeID, automationRunStatus)
+		cobra.CheckErr(err)
+		suggestions := policies.Evaluate(workloads, func(w models.Workload) map[string]string {
+			return workloadLabels(c, w)
+		})
+		if len(suggestions) == 0 {
+			_, _ = fmt.Fprintln(os.Stderr, "no policies matched")
+			return
+		}
+
+		if mode == "client" {
+			if strings.EqualFold(strings.TrimSpace(dryRunOutput), "yaml") {
+				for i, s := range suggestions {
+					if i > 0 {
+						fmt.Println("---")
+					}
+					cobra.CheckErr(printProtoAs(s, dryRunOutput))
+				}
+				return
+			}
+			out := make([]json.RawMessage, 0, len(suggestions))
+			for _, s := range suggestions {
+				b, err := protojson.Marshal(s)
+				cobra.CheckErr(err)
+				out = append(out, b)
+			}
+			data, err := json.MarshalIndent(out, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+
+		type result struct {
+			WorkloadID string `json:"workloadId"`
+			PolicyID   string `json:"policyId"`
+			Action     string `json:"action"`
+			Accepted   bool   `json:"accepted"`
+			Decision   string `json:"decision,omitempty"`
+			Reason     string `json:"reason,omitempty"`
+			Error      string `json:"error,omitempty"`
+		}
+		results := make([]result, 0, len(suggestions))
+		failed := 0
+		for _, s := range suggestions {
+			r := result{WorkloadID: s.GetTargetWorkload(), PolicyID: s.GetPolicyId(), Action: s.GetActionType().String()}
+			resp, err := c.SubmitAutomationSuggestion(s)
+			if err != nil {
+				r.Error = err.Error()
+				failed++
+			} else {
+				r.Accepted = resp.GetAccepted()
+				r.Decision = resp.GetDecision()
+				r.Reason = resp.GetReason()
+			}
+			results = append(results, r)
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+		if failed > 0 {
+			cobra.CheckErr(fmt.Errorf("%d of %d suggestions could not be submitted", failed, len(results)))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(automationCmd)
+	automationCmd.AddCommand(automationSuggestCmd)
+	automationCmd.AddCommand(automationRunCmd)
+
+	automationSuggestCmd.Flags().StringVar(&automationWorkloadID, "workload", "", "Target workload ID")
+	automationSuggestCmd.Flags().StringVar(&automationAction, "action", "", "Action: retry|set-desired-state|delete|scale")
+	automationSuggestCmd.Flags().StringVar(&automationDesiredState, "desired-state", "", "Desired state for set-desired-state: running|stopped")
+	automationSuggestCmd.Flags().Int32Var(&automationReplicas, "replicas", 0, "Desired replicas for scale")
+	automationSuggestCmd.Flags().Int32Var(&automationDelta, "delta", 0, "Replica delta for scale (e.g. 2 or -1)")
+	automationSuggestCmd.Flags().StringVar(&automationReason, "reason", "", "Reason recorded with the suggestion")
+	automationSuggestCmd.Flags().StringVar(&automationPolicyID, "policy-id", "manual", "Policy ID recorded with the suggestion")
+	cobra.CheckErr(automationSuggestCmd.MarkFlagRequired("workload"))
+	cobra.CheckErr(automationSuggestCmd.MarkFlagRequired("action"))
+	addDryRunFlags(automationSuggestCmd)
+
+	automationRunCmd.Flags().StringVarP(&automationPolicyFile, "file", "f", "", "Policy file (YAML or JSON)")
+	automationRunCmd.Flags().StringVar(&automationRunNodeID, "node", "", "Only evaluate workloads on this node")
+	automationRunCmd.Flags().StringVar(&automationRunStatus, "status", "", "Only evaluate workloads with this status")
+	cobra.CheckErr(automationRunCmd.MarkFlagRequired("file"))
+	addDryRunFlags(automationRunCmd)
+}
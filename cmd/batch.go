@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchSelectors []string
+	batchAll       bool
+	batchStatus    string
+	batchNodeID    string
+	batchParallel  int
+	batchYes       bool
+)
+
+// batchResult is one line of the per-workload summary.
+type batchResult struct {
+	WorkloadID string `json:"workloadId"`
+	OK         bool   `json:"ok"`
+	Message    string `json:"message,omitempty"`
+}
+
+// batchOp runs one operation against one workload. A false ok with a nil
+// error is a rejection reported by the scheduler.
+type batchOp func(c *client.Client, workloadID string) (ok bool, message string, err error)
+
+// addBatchFlags registers the selector flags that let a single-workload
+// command run against many workloads.
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&batchSelectors, "selector", "l", nil, "Label selector: key=value, key!=value or key (repeatable and comma-separated; all must match)")
+	cmd.Flags().BoolVar(&batchAll, "all", false, "Select all workloads (narrowed by --status/--node)")
+	cmd.Flags().StringVar(&batchStatus, "status", "", "Only select workloads with this status")
+	cmd.Flags().StringVar(&batchNodeID, "node", "", "Only select workloads assigned to this node")
+	cmd.Flags().IntVar(&batchParallel, "parallel", 4, "Maximum concurrent operations in batch mode")
+	cmd.Flags().BoolVarP(&batchYes, "yes", "y", false, "Skip the confirmation prompt in batch mode")
+}
+
+// batchRequested reports whether the command should run in batch mode. It
+// fails when neither an ID nor a selection was given, or both were.
+func batchRequested(id string) (bool, error) {
+	selecting := batchAll || len(batchSelectors) > 0 || batchStatus != "" || batchNodeID != ""
+	switch {
+	case strings.TrimSpace(id) != "" && selecting:
+		return false, fmt.Errorf("--id cannot be combined with -l, --all, --status or --node")
+	case strings.TrimSpace(id) != "":
+		return false, nil
+	case len(batchSelectors) == 0 && !batchAll:
+		return false, fmt.Errorf("one of --id, -l or --all is required")
+	default:
+		return true, nil
+	}
+}
+
+// runBatch selects workloads, asks for confirmation and runs op on each with
+// bounded parallelism, then prints the per-workload summary.
+func runBatch(verb string, op batchOp) {
+	selector, err := parseLabelSelector(batchSelectors)
+	cobra.CheckErr(err)
+
+	c, _, err := newClientWithTrace()
+	cobra.CheckErr(err)
+	defer c.Close()
+
+	workloads, err := c.ListWorkloads(batchNodeID, batchStatus)
+	cobra.CheckErr(err)
+	ids := make([]string, 0, len(workloads))
+	for _, w := range workloads {
+		if batchNodeID != "" && w.NodeID != batchNodeID {
+			continue
+		}
+		if batchStatus != "" && !strings.EqualFold(w.Status, batchStatus) {
+			continue
+		}
+		if len(selector) > 0 && !selector.matches(workloadLabels(c, w)) {
+			continue
+		}
+		ids = append(ids, w.ID)
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "no workloads matched")
+		return
+	}
+
+	if !batchYes {
+		ok, err := confirmBatch(verb, ids)
+		cobra.CheckErr(err)
+		if !ok {
+			_, _ = fmt.Fprintln(os.Stderr, "aborted")
+			return
+		}
+	}
+
+	parallel := batchParallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]batchResult, len(ids))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, msg, err := op(c, id)
+			if err != nil {
+				msg = err.Error()
+			}
+			results[i] = batchResult{WorkloadID: id, OK: ok && err == nil, Message: msg}
+		}(i, id)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+	}
+	data, err := json.MarshalIndent(map[string]any{
+		"operation": verb,
+		"total":     len(results),
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+		"results":   results,
+	}, "", "  ")
+	cobra.CheckErr(err)
+	fmt.Println(string(data))
+	if failed > 0 {
+		cobra.CheckErr(fmt.Errorf("%s failed for %d of %d workloads", verb, failed, len(results)))
+	}
+}
+
+func confirmBatch(verb string, ids []string) (bool, error) {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("refusing to %s %d workloads without a terminal; pass --yes", verb, len(ids))
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "The following %d workloads will be affected (%s):\n", len(ids), verb)
+	for _, id := range ids {
+		_, _ = fmt.Fprintf(os.Stderr, "  %s\n", id)
+	}
+	_, _ = fmt.Fprint(os.Stderr, "Proceed? [y/N]: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, nil
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// workloadLabels returns the labels known for w. Scheduler listings do not
+// carry spec metadata, so the latest locally recorded revision is used as a
+// fallback.
+func workloadLabels(c *client.Client, w models.Workload) map[string]string {
+	labels := make(map[string]string, len(w.Labels)+len(w.Metadata))
+	for k, v := range w.Metadata {
+		labels[k] = v
+	}
+	for k, v := range w.Labels {
+		labels[k] = v
+	}
+	if len(labels) > 0 {
+		return labels
+	}
+	entries, err := c.History().List(w.ID)
+	if err != nil || len(entries) == 0 {
+		return labels
+	}
+	var spec struct {
+		Metadata map[string]string `json:"metadata"`
+	}
+	if json.Unmarshal(entries[len(entries)-1].Spec, &spec) == nil {
+		for k, v := range spec.Metadata {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool
+	exists bool
+}
+
+type labelSelector []labelRequirement
+
+func parseLabelSelector(in []string) (labelSelector, error) {
+	var out labelSelector
+	for _, raw := range in {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			var req labelRequirement
+			switch {
+			case strings.Contains(part, "!="):
+				kv := strings.SplitN(part, "!=", 2)
+				req = labelRequirement{key: strings.TrimSpace(kv[0]), value: strings.TrimSpace(kv[1]), negate: true}
+			case strings.Contains(part, "="):
+				kv := strings.SplitN(part, "=", 2)
+				req = labelRequirement{key: strings.TrimSpace(kv[0]), value: strings.TrimSpace(strings.TrimPrefix(kv[1], "="))}
+			default:
+				req = labelRequirement{key: part, exists: true}
+			}
+			if req.key == "" {
+				return nil, fmt.Errorf("invalid label selector %q", part)
+			}
+			out = append(out, req)
+		}
+	}
+	return out, nil
+}
+
+func (s labelSelector) matches(labels map[string]string) bool {
+	for _, req := range s {
+		v, ok := labels[req.key]
+		switch {
+		case req.exists:
+			if !ok {
+				return false
+			}
+		case req.negate:
+			if ok && v == req.value {
+				return false
+			}
+		default:
+			if !ok || v != req.value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func batchDelete(c *client.Client, id string) (bool, string, error) {
+	resp, err := c.DeleteWorkload(id)
+	if err != nil {
+		return false, "", err
+	}
+	return resp.GetSuccess(), resp.GetErrorMessage(), nil
+}
+
+func batchRetry(c *client.Client, id string) (bool, string, error) {
+	resp, err := c.RetryWorkload(id)
+	if err != nil {
+		return false, "", err
+	}
+	if !resp.GetAccepted() {
+		return false, "retry not accepted", nil
+	}
+	return true, "", nil
+}
+
+func batchSetDesired(state string) batchOp {
+	return func(c *client.Client, id string) (bool, string, error) {
+		resp, err := c.ApplySchedulerWorkload(&controlv1.ApplyWorkloadRequest{
+			WorkloadId:   id,
+			DesiredState: state,
+		})
+		if err != nil {
+			return false, "", err
+		}
+		return resp.GetSuccess(), resp.GetErrorMessage(), nil
+	}
+}
+
+func batchRestart(c *client.Client, id string) (bool, string, error) {
+	ok, msg, err := batchSetDesired("Stopped")(c, id)
+	if err != nil || !ok {
+		return ok, msg, err
+	}
+	if err := waitForSchedulerWorkloadStatus(c, id, "Stopped", 90*time.Second); err != nil {
+		return false, "", fmt.Errorf("stop: %w", err)
+	}
+	return batchSetDesired("Running")(c, id)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	sel, err := parseLabelSelector([]string{"app=web, tier!=db", "canary", "env==prod", " , "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := labelSelector{
+		{key: "app", value: "web"},
+		{key: "tier", value: "db", negate: true},
+		{key: "canary", exists: true},
+		{key: "env", value: "prod"},
+	}
+	if !reflect.DeepEqual(sel, want) {
+		t.Fatalf("parseLabelSelector = %+v, want %+v", sel, want)
+	}
+
+	for _, in := range []string{"=web", "!=db", " = x", "app=web,=x"} {
+		if _, err := parseLabelSelector([]string{in}); err == nil {
+			t.Errorf("parseLabelSelector(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	tests := []struct {
+		selector string
+		labels   map[string]string
+		want     bool
+	}{
+		{"app=web", map[string]string{"app": "web"}, true},
+		{"app=web", map[string]string{"app": "api"}, false},
+		{"app=web", nil, false},
+		{"app=", map[string]string{"app": ""}, true},
+		{"tier!=db", map[string]string{"tier": "cache"}, true},
+		{"tier!=db", map[string]string{"tier": "db"}, false},
+		{"tier!=db", nil, true},
+		{"canary", map[string]string{"canary": ""}, true},
+		{"canary", map[string]string{"app": "web"}, false},
+		{"app=web,tier!=db,canary", map[string]string{"app": "web", "tier": "cache", "canary": "true"}, true},
+		{"app=web,tier!=db,canary", map[string]string{"app": "web", "tier": "db", "canary": "true"}, false},
+		{"", map[string]string{"app": "web"}, true},
+	}
+	for _, tt := range tests {
+		sel, err := parseLabelSelector([]string{tt.selector})
+		if err != nil {
+			t.Fatalf("parseLabelSelector(%q): %v", tt.selector, err)
+		}
+		if got := sel.matches(tt.labels); got != tt.want {
+			t.Errorf("%q matches %v = %v, want %v", tt.selector, tt.labels, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// benchKey marks generated workloads in their spec metadata with the run's
+// ID prefix.
+const benchKey = "persys.io/bench"
+
+var (
+	benchCount       int
+	benchRate        float64
+	benchConcurrency int
+	benchTypes       []string
+	benchPrefix      string
+	benchImage       string
+	benchCPU         int64
+	benchMem         int64
+	benchTimeout     time.Duration
+	benchPoll        time.Duration
+	benchCleanup     bool
+	benchOutput      string
+)
+
+// benchSample is the outcome of one generated workload.
+type benchSample struct {
+	id           string
+	applyLatency time.Duration
+	toRunning    time.Duration
+	applied      bool
+	outcome      string // running, failed, timeout, apply-error, rejected, cancelled
+	err          string
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Load-test the scheduler by applying synthetic workloads",
+	Long: `Generates synthetic container, compose and VM specs and applies them through
+the scheduler at a fixed rate and concurrency. Each workload is polled with
+GetWorkload until it is Running or Failed (or --timeout passes), and the run
+reports throughput and p50/p95/p99 latency for apply and apply-to-running.
+
+Generated workloads are deleted afterwards unless --cleanup=false.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if benchCount < 1 {
+			cobra.CheckErr(fmt.Errorf("--count must be at least 1"))
+		}
+		for _, t := range benchTypes {
+			if t != "container" && t != "compose" && t != "vm" {
+				cobra.CheckErr(fmt.Errorf("invalid --types entry %q (expected container|compose|vm)", t))
+			}
+		}
+		if benchPrefix == "" {
+			benchPrefix = fmt.Sprintf("bench-%d", time.Now().Unix())
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		concurrency := benchConcurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		jobs := make(chan int)
+		samples := make([]benchSample, benchCount)
+		var wg sync.WaitGroup
+		for w := 0; w < concurrency; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					samples[i] = runBenchWorkload(ctx, c, i)
+				}
+			}()
+		}
+
+		started := time.Now()
+		var tick <-chan time.Time
+		if benchRate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / benchRate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		dispatched := 0
+	dispatch:
+		for ; dispatched < benchCount; dispatched++ {
+			if tick != nil && dispatched > 0 {
+				select {
+				case <-ctx.Done():
+					break dispatch
+				case <-tick:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case jobs <- dispatched:
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		elapsed := time.Since(started)
+		for i := dispatched; i < benchCount; i++ {
+			samples[i] = benchSample{id: benchWorkloadID(i), outcome: "cancelled"}
+		}
+
+		report := buildBenchReport(samples, elapsed)
+		if benchCleanup {
+			report["cleanup"] = cleanupBench(c, samples, concurrency)
+		}
+		cobra.CheckErr(printBenchReport(report))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().IntVar(&benchCount, "count", 100, "Number of workloads to apply")
+	benchCmd.Flags().Float64Var(&benchRate, "rate", 10, "Applies started per second (0 = as fast as concurrency allows)")
+	benchCmd.Flags().IntVar(&benchConcurrency, "concurrency", 10, "Maximum workloads in flight")
+	benchCmd.Flags().StringSliceVar(&benchTypes, "types", []string{"container"}, "Workload types to generate, used round-robin: container,compose,vm")
+	benchCmd.Flags().StringVar(&benchPrefix, "prefix", "", "Workload ID prefix (default: bench-<unix time>)")
+	benchCmd.Flags().StringVar(&benchImage, "image", "nginx:alpine", "Image for container and compose workloads, OS image for VMs")
+	benchCmd.Flags().Int64Var(&benchCPU, "cpu", 100, "CPU request per workload in millicores")
+	benchCmd.Flags().Int64Var(&benchMem, "mem", 64, "Memory request per workload in MB")
+	benchCmd.Flags().DurationVar(&benchTimeout, "timeout", 2*time.Minute, "How long to wait for each workload to settle")
+	benchCmd.Flags().DurationVar(&benchPoll, "poll", time.Second, "GetWorkload polling interval")
+	benchCmd.Flags().BoolVar(&benchCleanup, "cleanup", true, "Delete generated workloads when the run ends")
+	benchCmd.Flags().StringVarP(&benchOutput, "output", "o", "table", "Output format: table|json")
+}
+
+func benchWorkloadID(i int) string {
+	return fmt.Sprintf("%s-%05d", benchPrefix, i)
+}
+
+// benchSpec generates the i-th synthetic spec.
+func benchSpec(i int) *controlv1.WorkloadSpec {
+	typ := benchTypes[i%len(benchTypes)]
+	spec := &controlv1.WorkloadSpec{
+		Type:      typ,
+		Resources: &controlv1.ResourceRequirements{CpuMillicores: benchCPU, MemoryMb: benchMem},
+		Metadata:  map[string]string{benchKey: benchPrefix},
+	}
+	switch typ {
+	case "compose":
+		spec.Workload = &controlv1.WorkloadSpec_Compose{Compose: &controlv1.ComposeSpec{
+			SourceType: "inline",
+			InlineYaml: fmt.Sprintf("services:\n  app:\n    image: %s\n", benchImage),
+		}}
+	case "vm":
+		vcpus := int32(benchCPU / 1000)
+		if vcpus < 1 {
+			vcpus = 1
+		}
+		spec.Workload = &controlv1.WorkloadSpec_Vm{Vm: &controlv1.VMSpec{
+			Vcpus:    vcpus,
+			MemoryMb: benchMem,
+			OsImage:  benchImage,
+			Networks: []*controlv1.NetworkConfig{{Dhcp: true}},
+		}}
+	default:
+		spec.Workload = &controlv1.WorkloadSpec_Container{Container: &controlv1.ContainerSpec{
+			Image:         benchImage,
+			RestartPolicy: "no",
+		}}
+	}
+	return spec
+}
+
+func runBenchWorkload(ctx context.Context, c *client.Client, i int) benchSample {
+	id := benchWorkloadID(i)
+	spec := benchSpec(i)
+	s := benchSample{id: id}
+
+	start := time.Now()
+	resp, err := c.ApplySchedulerWorkload(&controlv1.ApplyWorkloadRequest{
+		WorkloadId:   id,
+		RevisionId:   revisionFor("", spec),
+		DesiredState: "Running",
+		Spec:         spec,
+	})
+	s.applyLatency = time.Since(start)
+	if err != nil {
+		s.outcome, s.err = "apply-error", err.Error()
+		return s
+	}
+	s.applied = true
+	if !resp.GetSuccess() {
+		s.outcome, s.err = "rejected", resp.GetErrorMessage()
+		return s
+	}
+
+	deadline := start.Add(benchTimeout)
+	for {
+		if get, err := c.GetWorkload(id); err == nil && get.GetWorkload() != nil {
+			switch status := get.GetWorkload().GetStatus(); {
+			case strings.EqualFold(status, "Running"):
+				s.outcome, s.toRunning = "running", time.Since(start)
+				return s
+			case strings.EqualFold(status, "Failed"):
+				s.outcome, s.err = "failed", get.GetWorkload().GetFailureReason()
+				return s
+			}
+		}
+		if time.Now().After(deadline) {
+			s.outcome = "timeout"
+			return s
+		}
+		select {
+		case <-ctx.Done():
+			s.outcome = "cancelled"
+			return s
+		case <-time.After(benchPoll):
+		}
+	}
+}
+
+func buildBenchReport(samples []benchSample, elapsed time.Duration) map[string]any {
+	outcomes := map[string]int{}
+	var applyLat, runLat []time.Duration
+	errorCounts := map[string]int{}
+	for _, s := range samples {
+		outcomes[s.outcome]++
+		if s.applied {
+			applyLat = append(applyLat, s.applyLatency)
+		}
+		if s.outcome == "running" {
+			runLat = append(runLat, s.toRunning)
+		}
+		if s.err != "" {
+			errorCounts[s.err]++
+		}
+	}
+	secs := elapsed.Seconds()
+	report := map[string]any{
+		"prefix":             benchPrefix,
+		"total":              len(samples),
+		"outcomes":           outcomes,
+		"elapsedSeconds":     round3(secs),
+		"appliesPerSec":      round3(float64(len(applyLat)) / secs),
+		"runningPerSec":      round3(float64(len(runLat)) / secs),
+		"applyLatencyMs":     latencySummary(applyLat),
+		"toRunningLatencyMs": latencySummary(runLat),
+	}
+	if len(errorCounts) > 0 {
+		report["errors"] = errorCounts
+	}
+	return report
+}
+
+// latencySummary returns nearest-rank percentiles in milliseconds.
+func latencySummary(d []time.Duration) map[string]float64 {
+	if len(d) == 0 {
+		return nil
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	pct := func(p float64) float64 {
+		idx := int(p*float64(len(d))+0.999999) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(d) {
+			idx = len(d) - 1
+		}
+		return round3(float64(d[idx]) / float64(time.Millisecond))
+	}
+	return map[string]float64{
+		"p50": pct(0.50),
+		"p95": pct(0.95),
+		"p99": pct(0.99),
+		"max": round3(float64(d[len(d)-1]) / float64(time.Millisecond)),
+	}
+}
+
+func round3(v float64) float64 {
+	return float64(int64(v*1000+0.5)) / 1000
+}
+
+// cleanupBench deletes every workload the run created. The scheduler listing
+// is searched for the run's benchKey marker and ID prefix so that workloads
+// whose apply failed client-side (e.g. a deadline after the scheduler
+// accepted them) are removed too. If listing fails, every sample that may
+// have reached the scheduler is deleted instead.
+func cleanupBench(c *client.Client, samples []benchSample, concurrency int) map[string]int {
+	ids := map[string]bool{}
+	for _, s := range samples {
+		if s.applied {
+			ids[s.id] = true
+		}
+	}
+	if workloads, err := c.ListWorkloads("", ""); err == nil {
+		for _, id := range benchRunWorkloads(workloads, benchPrefix) {
+			ids[id] = true
+		}
+	} else {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: list workloads for cleanup: %v\n", err)
+		for _, s := range samples {
+			if s.outcome == "apply-error" {
+				ids[s.id] = true
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	result := map[string]int{}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			key := "deleted"
+			if resp, err := c.DeleteWorkload(id); err != nil || !resp.GetSuccess() {
+				key = "failed"
+			}
+			mu.Lock()
+			result[key]++
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return result
+}
+
+// benchRunWorkloads returns the live workloads generated by the run with the
+// given prefix: those marked with benchKey, or named <prefix>-NNNNN.
+func benchRunWorkloads(workloads []models.Workload, prefix string) []string {
+	var ids []string
+	for _, w := range workloads {
+		if workloadDeleted(w) {
+			continue
+		}
+		if w.Metadata[benchKey] == prefix || benchRunID(w.ID, prefix) {
+			ids = append(ids, w.ID)
+		}
+	}
+	return ids
+}
+
+// benchRunID reports whether id has the form benchWorkloadID produces.
+func benchRunID(id, prefix string) bool {
+	n, ok := strings.CutPrefix(id, prefix+"-")
+	if !ok || len(n) < 5 {
+		return false
+	}
+	for _, r := range n {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func printBenchReport(report map[string]any) error {
+	if strings.EqualFold(benchOutput, "json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "prefix\t%v\n", report["prefix"])
+	_, _ = fmt.Fprintf(tw, "workloads\t%v\n", report["total"])
+	outcomes := report["outcomes"].(map[string]int)
+	keys := make([]string, 0, len(outcomes))
+	for k := range outcomes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(tw, "  %s\t%d\n", k, outcomes[k])
+	}
+	_, _ = fmt.Fprintf(tw, "elapsed\t%vs\n", report["elapsedSeconds"])
+	_, _ = fmt.Fprintf(tw, "throughput\t%v applies/s, %v running/s\n", report["appliesPerSec"], report["runningPerSec"])
+	for _, name := range []string{"applyLatencyMs", "toRunningLatencyMs"} {
+		lat, _ := report[name].(map[string]float64)
+		if lat == nil {
+			_, _ = fmt.Fprintf(tw, "%s\t-\n", name)
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "%s\tp50=%v p95=%v p99=%v max=%v\n", name, lat["p50"], lat["p95"], lat["p99"], lat["max"])
+	}
+	if errs, ok := report["errors"].(map[string]int); ok {
+		_, _ = fmt.Fprintln(tw, "errors\t")
+		for msg, n := range errs {
+			_, _ = fmt.Fprintf(tw, "  %dx\t%s\n", n, msg)
+		}
+	}
+	if cleanup, ok := report["cleanup"].(map[string]int); ok {
+		_, _ = fmt.Fprintf(tw, "cleanup\tdeleted=%d failed=%d\n", cleanup["deleted"], cleanup["failed"])
+	}
+	return tw.Flush()
+}
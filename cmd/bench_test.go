@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+func TestBenchRunWorkloads(t *testing.T) {
+	workloads := []models.Workload{
+		{ID: "bench-1-00000", Status: "Running"},
+		// Marked by the run, whatever its name.
+		{ID: "renamed", Status: "Pending", Metadata: map[string]string{benchKey: "bench-1"}},
+		// Accepted by the scheduler after the client's apply deadline.
+		{ID: "bench-1-00042", Status: "Pending"},
+		{ID: "bench-1-00003", Status: "Deleted"},
+		{ID: "bench-1-web", Status: "Running"},
+		{ID: "bench-10-00000", Status: "Running"},
+		{ID: "other", Status: "Running", Metadata: map[string]string{benchKey: "bench-2"}},
+	}
+	want := []string{"bench-1-00000", "renamed", "bench-1-00042"}
+	if got := benchRunWorkloads(workloads, "bench-1"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("benchRunWorkloads = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRunMode   string
+	dryRunOutput string
+)
+
+// addDryRunFlags registers --dry-run and its output format on apply commands.
+// A bare --dry-run means --dry-run=client.
+func addDryRunFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&dryRunMode, "dry-run", "none", "Preview the request: none|client|server (client prints it without dialing)")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "client"
+	cmd.Flags().StringVarP(&dryRunOutput, "output", "o", "json", "Dry-run output format: json|yaml")
+}
+
+func dryRunValue() (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(dryRunMode))
+	switch mode {
+	case "", "none":
+		return "none", nil
+	case "client", "server":
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid --dry-run %q (expected none, client or server)", dryRunMode)
+	}
+}
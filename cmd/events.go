@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	schedulerEventsTypes      []string
+	schedulerEventsNode       string
+	schedulerEventsWorkload   string
+	schedulerEventsOutput     string
+	schedulerEventsMaxBackoff time.Duration
+	schedulerEventsNoRetry    bool
+)
+
+var controlEventTypes = []string{"register", "heartbeat", "apply", "delete"}
+
+var schedulerEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Stream control-plane events from the scheduler ControlStream",
+	Long: `Keeps the scheduler ControlStream open and prints every register, heartbeat,
+apply and delete message as it arrives. The stream is reopened with
+exponential backoff when the scheduler closes it or becomes unavailable;
+other errors (configuration, authentication, permissions) exit immediately.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		types := map[string]bool{}
+		for _, raw := range schedulerEventsTypes {
+			for _, t := range strings.Split(raw, ",") {
+				t = strings.ToLower(strings.TrimSpace(t))
+				if t == "" {
+					continue
+				}
+				if !slices.Contains(controlEventTypes, t) {
+					cobra.CheckErr(fmt.Errorf("invalid --type %q (expected %s)", t, strings.Join(controlEventTypes, "|")))
+				}
+				types[t] = true
+			}
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		backoff := time.Second
+		for {
+			received := false
+			err := c.WatchControlStream(ctx, func(msg *controlv1.ControlMessage) error {
+				received = true
+				event := controlEventType(msg)
+				if len(types) > 0 && !types[event] {
+					return nil
+				}
+				if !controlEventMatches(msg, schedulerEventsNode, schedulerEventsWorkload) {
+					return nil
+				}
+				return printControlEvent(event, msg)
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if !retryableStreamError(err) {
+				cobra.CheckErr(err)
+			}
+			if schedulerEventsNoRetry {
+				if err == nil || errors.Is(err, io.EOF) {
+					return
+				}
+				cobra.CheckErr(err)
+			}
+			if received {
+				backoff = time.Second
+			}
+			reason := "stream closed by scheduler"
+			if err != nil && !errors.Is(err, io.EOF) {
+				reason = err.Error()
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "%s event stream disconnected (%s); reconnecting in %s\n",
+				time.Now().UTC().Format(time.RFC3339), reason, backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > schedulerEventsMaxBackoff {
+				backoff = schedulerEventsMaxBackoff
+			}
+		}
+	},
+}
+
+func init() {
+	schedulerCmd.AddCommand(schedulerEventsCmd)
+
+	schedulerEventsCmd.Flags().StringArrayVar(&schedulerEventsTypes, "type", nil, "Only show these event types: register|heartbeat|apply|delete (repeatable or comma-separated)")
+	schedulerEventsCmd.Flags().StringVar(&schedulerEventsNode, "node", "", "Only show register/heartbeat events from this node")
+	schedulerEventsCmd.Flags().StringVar(&schedulerEventsWorkload, "workload", "", "Only show events that mention this workload")
+	schedulerEventsCmd.Flags().StringVarP(&schedulerEventsOutput, "output", "o", "text", "Output format: text|json (one object per line)")
+	schedulerEventsCmd.Flags().DurationVar(&schedulerEventsMaxBackoff, "max-backoff", 30*time.Second, "Maximum delay between reconnect attempts")
+	schedulerEventsCmd.Flags().BoolVar(&schedulerEventsNoRetry, "no-reconnect", false, "Exit when the stream drops instead of reconnecting")
+}
+
+// retryableStreamError reports whether reopening the stream can help: the
+// scheduler closed it or is unavailable.
+func retryableStreamError(err error) bool {
+	return err == nil || errors.Is(err, io.EOF) || status.Code(err) == codes.Unavailable
+}
+
+func controlEventType(msg *controlv1.ControlMessage) string {
+	switch {
+	case msg.GetRegister() != nil:
+		return "register"
+	case msg.GetHeartbeat() != nil:
+		return "heartbeat"
+	case msg.GetApply() != nil:
+		return "apply"
+	case msg.GetDelete() != nil:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// controlEventMatches applies --node and --workload. Heartbeats match a
+// workload when they carry its status or usage.
+func controlEventMatches(msg *controlv1.ControlMessage, nodeID, workloadID string) bool {
+	if nodeID != "" {
+		switch {
+		case msg.GetRegister() != nil:
+			if msg.GetRegister().GetNodeId() != nodeID {
+				return false
+			}
+		case msg.GetHeartbeat() != nil:
+			if msg.GetHeartbeat().GetNodeId() != nodeID {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	if workloadID != "" {
+		switch {
+		case msg.GetApply() != nil:
+			return msg.GetApply().GetWorkloadId() == workloadID
+		case msg.GetDelete() != nil:
+			return msg.GetDelete().GetWorkloadId() == workloadID
+		case msg.GetHeartbeat() != nil:
+			for _, s := range msg.GetHeartbeat().GetWorkloadStatuses() {
+				if s.GetWorkloadId() == workloadID {
+					return true
+				}
+			}
+			for _, u := range msg.GetHeartbeat().GetWorkloadUsage() {
+				if u.GetWorkloadId() == workloadID {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// controlEventTime prefers the timestamp carried by the message over the
+// time it was received.
+func controlEventTime(msg *controlv1.ControlMessage) time.Time {
+	var ts *timestamppb.Timestamp
+	switch {
+	case msg.GetRegister() != nil:
+		ts = msg.GetRegister().GetTimestamp()
+	case msg.GetHeartbeat() != nil:
+		ts = msg.GetHeartbeat().GetTimestamp()
+	}
+	if ts != nil && ts.IsValid() {
+		return ts.AsTime().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func printControlEvent(event string, msg *controlv1.ControlMessage) error {
+	at := controlEventTime(msg)
+	if strings.EqualFold(schedulerEventsOutput, "json") {
+		payload, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(map[string]any{
+			"time":    at.Format(time.RFC3339Nano),
+			"type":    event,
+			"message": json.RawMessage(payload),
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(line))
+		return nil
+	}
+
+	var detail string
+	switch event {
+	case "register":
+		r := msg.GetRegister()
+		detail = fmt.Sprintf("node=%s endpoint=%s agent=%s", r.GetNodeId(), valueOrDash(r.GetGrpcEndpoint()), valueOrDash(r.GetAgentVersion()))
+	case "heartbeat":
+		h := msg.GetHeartbeat()
+		detail = fmt.Sprintf("node=%s workloads=%d", h.GetNodeId(), len(h.GetWorkloadStatuses()))
+		for _, s := range h.GetWorkloadStatuses() {
+			if schedulerEventsWorkload != "" && s.GetWorkloadId() == schedulerEventsWorkload {
+				detail += fmt.Sprintf(" %s=%s", s.GetWorkloadId(), s.GetState())
+			}
+		}
+	case "apply":
+		a := msg.GetApply()
+		detail = fmt.Sprintf("workload=%s revision=%s desired=%s", a.GetWorkloadId(), valueOrDash(a.GetRevisionId()), valueOrDash(a.GetDesiredState()))
+	case "delete":
+		detail = fmt.Sprintf("workload=%s", msg.GetDelete().GetWorkloadId())
+	}
+	fmt.Printf("%s %-9s %s\n", at.Format(time.RFC3339), strings.ToUpper(event), detail)
+	return nil
+}
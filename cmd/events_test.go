@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryableStreamError(t *testing.T) {
+	retry := []error{
+		nil,
+		io.EOF,
+		status.Error(codes.Unavailable, "connection refused"),
+		fmt.Errorf("control stream: %w", status.Error(codes.Unavailable, "goaway")),
+	}
+	for _, err := range retry {
+		if !retryableStreamError(err) {
+			t.Errorf("retryableStreamError(%v) = false, want true", err)
+		}
+	}
+	fail := []error{
+		errors.New("this operation requires gRPC transport (set --transport grpc)"),
+		status.Error(codes.Unauthenticated, "bad certificate"),
+		status.Error(codes.PermissionDenied, "denied"),
+		status.Error(codes.Unimplemented, "unknown method"),
+	}
+	for _, err := range fail {
+		if retryableStreamError(err) {
+			t.Errorf("retryableStreamError(%v) = true, want false", err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/persys-dev/persysctl/internal/agentstream"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// detachKey (Ctrl-]) ends an interactive attach without stopping the
+// workload.
+const detachKey = 0x1d
+
+var (
+	workloadExecStdin bool
+	workloadExecTTY   bool
+	workloadAttachTTY bool
+)
+
+var workloadExecCmd = &cobra.Command{
+	Use:   "exec <id> -- <command> [args...]",
+	Short: "Run a command in a running workload (experimental)",
+	Long: `Runs a command in a running workload over the compute-agent Exec stream.
+
+Experimental: the stream belongs to persysctl.experimental.v1.WorkloadStreams,
+which is not part of the persys-cloud agent API yet; agents without it are
+reported as unsupported.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("requires a workload ID and a command after --")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		code, err := runWorkloadSession(agentstream.ExecStart{
+			WorkloadID: args[0],
+			Command:    args[1:],
+			TTY:        workloadExecTTY,
+			Stdin:      workloadExecStdin || workloadExecTTY,
+		}, false)
+		cobra.CheckErr(err)
+		if code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+var workloadAttachCmd = &cobra.Command{
+	Use:   "attach <id>",
+	Short: "Attach to a workload's main process or VM serial console (experimental; Ctrl-] detaches)",
+	Long: `Attaches to a workload's main process, or the serial console of a VM
+workload, over the compute-agent Attach stream. Ctrl-] detaches.
+
+Experimental: the stream belongs to persysctl.experimental.v1.WorkloadStreams,
+which is not part of the persys-cloud agent API yet.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tty := workloadAttachTTY
+		if !cmd.Flags().Changed("tty") {
+			tty = term.IsTerminal(int(os.Stdin.Fd()))
+		}
+		code, err := runWorkloadSession(agentstream.ExecStart{
+			WorkloadID: args[0],
+			TTY:        tty,
+			Stdin:      true,
+		}, true)
+		cobra.CheckErr(err)
+		if code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadExecCmd)
+	workloadCmd.AddCommand(workloadAttachCmd)
+
+	workloadExecCmd.Flags().BoolVarP(&workloadExecStdin, "stdin", "i", false, "Pass stdin to the command")
+	workloadExecCmd.Flags().BoolVarP(&workloadExecTTY, "tty", "t", false, "Allocate a TTY (implies --stdin)")
+	workloadAttachCmd.Flags().BoolVarP(&workloadAttachTTY, "tty", "t", false, "Use raw TTY mode (default: when stdin is a terminal)")
+}
+
+// runWorkloadSession runs an exec/attach session and returns the remote exit
+// code. Errors after the terminal is switched to raw mode are returned, not
+// fatal, so the terminal is always restored.
+func runWorkloadSession(start agentstream.ExecStart, attach bool) (int, error) {
+	c, _, err := newClientWithTrace()
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdinFd := int(os.Stdin.Fd())
+	stdoutFd := int(os.Stdout.Fd())
+	raw := start.TTY && term.IsTerminal(stdinFd)
+	if raw {
+		if cols, rows, err := term.GetSize(stdoutFd); err == nil {
+			start.Size = &agentstream.TerminalSize{Cols: uint32(cols), Rows: uint32(rows)}
+		}
+	}
+
+	session, closeConn, err := c.OpenWorkloadSession(ctx, start, attach)
+	if errors.Is(err, agentstream.ErrUnsupported) {
+		return 0, fmt.Errorf("interactive sessions are not available for workload %s: %w", start.WorkloadID, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer closeConn()
+
+	if raw {
+		state, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return 0, fmt.Errorf("set terminal raw mode: %w", err)
+		}
+		defer func() { _ = term.Restore(stdinFd, state) }()
+
+		var mu sync.Mutex
+		last := start.Size
+		watchTerminalResize(ctx, func() {
+			cols, rows, err := term.GetSize(stdoutFd)
+			if err != nil {
+				return
+			}
+			size := agentstream.TerminalSize{Cols: uint32(cols), Rows: uint32(rows)}
+			mu.Lock()
+			defer mu.Unlock()
+			if last != nil && *last == size {
+				return
+			}
+			last = &size
+			_ = session.Resize(size)
+		})
+	}
+
+	detached := make(chan struct{})
+	if start.Stdin {
+		go pumpStdin(session, attach && raw, detached)
+	}
+
+	type result struct {
+		code int
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		code, err := copySessionOutput(session)
+		done <- result{code, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.code, r.err
+	case <-detached:
+		_ = session.Close()
+		_, _ = fmt.Fprint(os.Stderr, "\r\ndetached\r\n")
+		return 0, nil
+	}
+}
+
+// pumpStdin forwards stdin to the session. With detach set, Ctrl-] closes
+// detached instead of being forwarded.
+func pumpStdin(session *agentstream.Session, detach bool, detached chan<- struct{}) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			data := buf[:n]
+			if detach {
+				for i, b := range data {
+					if b == detachKey {
+						if i > 0 {
+							_ = session.SendStdin(data[:i])
+						}
+						close(detached)
+						return
+					}
+				}
+			}
+			if sendErr := session.SendStdin(data); sendErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			_ = session.CloseStdin()
+			return
+		}
+	}
+}
+
+func copySessionOutput(session *agentstream.Session) (int, error) {
+	for {
+		frame, err := session.Recv()
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(frame.Stdout) > 0 {
+			_, _ = os.Stdout.Write(frame.Stdout)
+		}
+		if len(frame.Stderr) > 0 {
+			_, _ = os.Stderr.Write(frame.Stderr)
+		}
+		if frame.Exit != nil {
+			if frame.Exit.Message != "" && frame.Exit.Code != 0 {
+				_, _ = fmt.Fprintln(os.Stderr, frame.Exit.Message)
+			}
+			return int(frame.Exit.Code), nil
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workloadExplainOutput  string
+	workloadExplainActions int32
+)
+
+// explainFinding is one observation about a workload with what to do
+// about it.
+type explainFinding struct {
+	Severity     string   `json:"severity"` // error, warning, info
+	Summary      string   `json:"summary"`
+	Detail       string   `json:"detail,omitempty"`
+	Remediations []string `json:"remediations,omitempty"`
+}
+
+type explainRetry struct {
+	Attempts    int32     `json:"attempts"`
+	MaxAttempts int32     `json:"maxAttempts"`
+	NextRetryAt time.Time `json:"nextRetryAt,omitzero"`
+	Retryable   *bool     `json:"retryable,omitempty"`
+	Summary     string    `json:"summary"`
+}
+
+type explainNode struct {
+	ID                string    `json:"id"`
+	Status            string    `json:"status"`
+	StatusReason      string    `json:"statusReason,omitempty"`
+	LastHeartbeat     time.Time `json:"lastHeartbeat,omitzero"`
+	AvailableCPU      float64   `json:"availableCpuCores"`
+	TotalCPU          float64   `json:"totalCpuCores"`
+	AvailableMemoryMB int64     `json:"availableMemoryMb"`
+	TotalMemoryMB     int64     `json:"totalMemoryMb"`
+}
+
+type explanation struct {
+	WorkloadID    string           `json:"workloadId"`
+	Type          string           `json:"type"`
+	Status        string           `json:"status"`
+	DesiredState  string           `json:"desiredState"`
+	RevisionID    string           `json:"revisionId,omitempty"`
+	FailureReason string           `json:"failureReason,omitempty"`
+	Message       string           `json:"message,omitempty"`
+	Node          *explainNode     `json:"node,omitempty"`
+	Retry         *explainRetry    `json:"retry,omitempty"`
+	Findings      []explainFinding `json:"findings"`
+	RecentActions []explainAction  `json:"recentActions,omitempty"`
+	ActionsError  string           `json:"actionsError,omitempty"`
+}
+
+// explainAction is one agent action record.
+type explainAction struct {
+	Type   string    `json:"actionType"`
+	Status string    `json:"status"`
+	At     time.Time `json:"at,omitzero"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// failureAdvice describes each scheduler FailureReason.
+var failureAdvice = map[string]explainFinding{
+	"IMAGE_PULL_FAILED": {
+		Summary: "The agent could not pull the workload image.",
+		Remediations: []string{
+			"Check the image reference and tag in the spec.",
+			"Check registry credentials (secret references are resolved by persysctl before apply).",
+			"Check that the node can reach the registry.",
+		},
+	},
+	"IMAGE_NOT_FOUND": {
+		Summary: "The image or tag does not exist in the registry.",
+		Remediations: []string{
+			"Fix the image reference and re-apply the spec; retrying the same spec will not help.",
+		},
+	},
+	"INSUFFICIENT_RESOURCES": {
+		Summary: "The node did not have enough CPU, memory or disk for the workload's requests.",
+		Remediations: []string{
+			"Lower the workload's resource requests, or free capacity on the node.",
+			"Compare requests with node capacity: persysctl node top",
+		},
+	},
+	"INVALID_SPEC": {
+		Summary: "The agent rejected the workload spec as invalid.",
+		Remediations: []string{
+			"Fix the spec and re-apply it; preview with --dry-run=server first.",
+		},
+	},
+	"RUNTIME_ERROR": {
+		Summary: "The workload started but its runtime reported an error (crash, bad command or exit).",
+		Remediations: []string{
+			"Read the workload output: persysctl workload logs <id> --tail 100",
+			"Check the command, environment and mounted volumes in the spec.",
+		},
+	},
+	"NETWORK_ERROR": {
+		Summary: "A network operation failed on the node (port binding, network setup or connectivity).",
+		Remediations: []string{
+			"Check for port conflicts with other workloads on the node.",
+			"Check the node's network; transient errors usually clear on retry.",
+		},
+	},
+	"STORAGE_ERROR": {
+		Summary: "A volume or disk could not be created or mounted.",
+		Remediations: []string{
+			"Check managed volume drivers and sizes against the node's storage pools.",
+			"Check that host paths in volume mounts exist on the node.",
+		},
+	},
+	"VM_BOOT_FAILED": {
+		Summary: "The virtual machine did not boot.",
+		Remediations: []string{
+			"Check the OS image and disk configuration.",
+			"Check cloud-init user data.",
+			"Watch the serial console: persysctl workload attach <id>",
+		},
+	},
+}
+
+var workloadExplainCmd = &cobra.Command{
+	Use:   "explain <id>",
+	Short: "Diagnose why a workload is not running",
+	Long: `Correlates the scheduler's view of a workload, its assigned node and the
+compute-agent's action history, and prints a diagnosis with suggested
+remediations and retry timing.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		resp, err := c.GetWorkload(args[0])
+		cobra.CheckErr(err)
+		w := resp.GetWorkload()
+		if w == nil {
+			cobra.CheckErr(fmt.Errorf("workload %s not found", args[0]))
+		}
+
+		var node *controlv1.NodeView
+		var nodeErr error
+		if nodeID := strings.TrimSpace(w.GetAssignedNodeId()); nodeID != "" {
+			nresp, err := c.GetNode(nodeID)
+			if err != nil {
+				nodeErr = err
+			} else {
+				node = nresp.GetNode()
+			}
+		}
+
+		var actions []explainAction
+		var actionsErr error
+		if workloadExplainActions > 0 && w.GetAssignedNodeId() != "" {
+			aresp, err := c.WorkloadActions(w.GetWorkloadId(), workloadExplainActions)
+			if err != nil {
+				actionsErr = err
+			} else {
+				actions = explainActions(aresp)
+			}
+		}
+
+		ex := explainWorkload(w, node, nodeErr, actions, time.Now())
+		if actionsErr != nil {
+			ex.ActionsError = actionsErr.Error()
+		}
+
+		if strings.EqualFold(workloadExplainOutput, "json") {
+			data, err := json.MarshalIndent(ex, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+		printExplanation(ex)
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadExplainCmd)
+	workloadExplainCmd.Flags().StringVarP(&workloadExplainOutput, "output", "o", "text", "Output format: text|json")
+	workloadExplainCmd.Flags().Int32Var(&workloadExplainActions, "actions", 10, "Number of recent agent actions to include (0 skips the agent)")
+}
+
+func explainWorkload(w *controlv1.WorkloadView, node *controlv1.NodeView, nodeErr error, actions []explainAction, now time.Time) *explanation {
+	ex := &explanation{
+		WorkloadID:    w.GetWorkloadId(),
+		Type:          w.GetType(),
+		Status:        w.GetStatus(),
+		DesiredState:  w.GetDesiredState(),
+		RevisionID:    w.GetRevisionId(),
+		FailureReason: w.GetFailureReason(),
+		Message:       w.GetReason().GetMessage(),
+		RecentActions: actions,
+	}
+	status := strings.ToLower(w.GetStatus())
+	desired := strings.ToLower(w.GetDesiredState())
+	id := w.GetWorkloadId()
+
+	reason := strings.ToUpper(strings.TrimSpace(w.GetFailureReason()))
+	if reason == "" || reason == "FAILURE_REASON_UNSPECIFIED" {
+		reason = strings.ToUpper(strings.TrimSpace(w.GetReason().GetCode()))
+	}
+	if advice, ok := failureAdvice[reason]; ok {
+		f := advice
+		f.Severity = "error"
+		f.Summary = reason + ": " + f.Summary
+		f.Detail = w.GetReason().GetMessage()
+		f.Remediations = make([]string, len(advice.Remediations))
+		for i, r := range advice.Remediations {
+			f.Remediations[i] = strings.ReplaceAll(r, "<id>", id)
+		}
+		if reason == "INSUFFICIENT_RESOURCES" && node != nil {
+			f.Detail = strings.TrimSpace(f.Detail + fmt.Sprintf(" Node %s has %.2f of %.2f CPU cores and %d of %d MB memory available.",
+				node.GetNodeId(), node.GetAvailableCpuCores(), node.GetTotalCpuCores(), node.GetAvailableMemoryMb(), node.GetTotalMemoryMb()))
+		}
+		ex.Findings = append(ex.Findings, f)
+	} else if reason != "" && reason != "FAILURE_REASON_UNSPECIFIED" {
+		ex.Findings = append(ex.Findings, explainFinding{
+			Severity: "error",
+			Summary:  "Failure reason " + reason + ".",
+			Detail:   w.GetReason().GetMessage(),
+		})
+	}
+
+	switch {
+	case w.GetAssignedNodeId() == "" && status != "running" && status != "stopped" && status != "deleted":
+		ex.Findings = append(ex.Findings, explainFinding{
+			Severity: "warning",
+			Summary:  "The workload has not been placed on a node.",
+			Detail:   "No Ready node may have enough free capacity or support this workload type.",
+			Remediations: []string{
+				"Check node capacity and readiness: persysctl node top",
+			},
+		})
+	case nodeErr != nil:
+		ex.Findings = append(ex.Findings, explainFinding{
+			Severity: "warning",
+			Summary:  fmt.Sprintf("Assigned node %s could not be read: %v", w.GetAssignedNodeId(), nodeErr),
+		})
+	case node != nil:
+		ex.Node = &explainNode{
+			ID:                node.GetNodeId(),
+			Status:            node.GetStatus(),
+			StatusReason:      node.GetStatusReason(),
+			AvailableCPU:      node.GetAvailableCpuCores(),
+			TotalCPU:          node.GetTotalCpuCores(),
+			AvailableMemoryMB: node.GetAvailableMemoryMb(),
+			TotalMemoryMB:     node.GetTotalMemoryMb(),
+		}
+		if node.GetLastHeartbeat() != nil {
+			ex.Node.LastHeartbeat = node.GetLastHeartbeat().AsTime()
+		}
+		if !strings.EqualFold(node.GetStatus(), "Ready") {
+			ex.Findings = append(ex.Findings, explainFinding{
+				Severity: "error",
+				Summary:  fmt.Sprintf("Assigned node %s is %s.", node.GetNodeId(), node.GetStatus()),
+				Detail:   node.GetStatusReason(),
+				Remediations: []string{
+					fmt.Sprintf("Check the node's agent: persysctl node health --id %s", node.GetNodeId()),
+				},
+			})
+		}
+		if !ex.Node.LastHeartbeat.IsZero() && now.Sub(ex.Node.LastHeartbeat) > time.Minute {
+			ex.Findings = append(ex.Findings, explainFinding{
+				Severity: "warning",
+				Summary: fmt.Sprintf("Node %s last sent a heartbeat %s ago; the scheduler's status for this workload may be stale.",
+					node.GetNodeId(), now.Sub(ex.Node.LastHeartbeat).Round(time.Second)),
+			})
+		}
+	}
+
+	if desired != "" && status != "" && desired != status && status != "failed" {
+		ex.Findings = append(ex.Findings, explainFinding{
+			Severity: "info",
+			Summary:  fmt.Sprintf("Desired state is %s but the workload is %s; the scheduler is still converging.", w.GetDesiredState(), w.GetStatus()),
+		})
+	}
+
+	ex.Retry = explainRetryState(w, now)
+
+	if failed := failedActions(actions); len(failed) > 0 {
+		ex.Findings = append(ex.Findings, explainFinding{
+			Severity: "warning",
+			Summary:  fmt.Sprintf("%d of the last %d agent actions failed.", len(failed), len(actions)),
+			Detail:   strings.Join(failed, "; "),
+		})
+	}
+
+	if len(ex.Findings) == 0 {
+		ex.Findings = append(ex.Findings, explainFinding{
+			Severity: "info",
+			Summary:  "No problems detected.",
+		})
+	}
+	return ex
+}
+
+func explainRetryState(w *controlv1.WorkloadView, now time.Time) *explainRetry {
+	r := &explainRetry{Attempts: w.GetRetryAttempts(), MaxAttempts: w.GetRetryMaxAttempts()}
+	next := w.GetRetryNextAt()
+	if next == nil {
+		next = w.GetReason().GetNextRetryAt()
+	}
+	if next != nil {
+		r.NextRetryAt = next.AsTime()
+	}
+	if w.GetReason() != nil && w.GetReason().GetCode() != "" {
+		retryable := w.GetReason().GetRetryable()
+		r.Retryable = &retryable
+	}
+	id := w.GetWorkloadId()
+	switch {
+	case r.Attempts == 0 && r.MaxAttempts == 0 && r.NextRetryAt.IsZero():
+		return nil
+	case r.Retryable != nil && !*r.Retryable:
+		r.Summary = fmt.Sprintf("The scheduler will not retry this failure automatically; fix the cause, then run: persysctl workload retry --id %s", id)
+	case r.MaxAttempts > 0 && r.Attempts >= r.MaxAttempts:
+		r.Summary = fmt.Sprintf("Automatic retries are exhausted (%d/%d); after fixing the cause run: persysctl workload retry --id %s", r.Attempts, r.MaxAttempts, id)
+	case !r.NextRetryAt.IsZero() && r.NextRetryAt.After(now):
+		r.Summary = fmt.Sprintf("Attempt %d of %d; next automatic retry in %s (%s).",
+			r.Attempts+1, r.MaxAttempts, r.NextRetryAt.Sub(now).Round(time.Second), r.NextRetryAt.UTC().Format(time.RFC3339))
+	case !r.NextRetryAt.IsZero():
+		r.Summary = fmt.Sprintf("A retry was due at %s and should be in progress.", r.NextRetryAt.UTC().Format(time.RFC3339))
+	default:
+		r.Summary = fmt.Sprintf("%d of %d retry attempts used.", r.Attempts, r.MaxAttempts)
+	}
+	return r
+}
+
+// explainActions converts a ListActions response. Agent timestamps are unix
+// seconds; an action is placed at its completion, or its start while running.
+// Failed actions without an error fall back to their message.
+func explainActions(resp *agentv1.ListActionsResponse) []explainAction {
+	out := make([]explainAction, 0, len(resp.GetActions()))
+	for _, r := range resp.GetActions() {
+		a := explainAction{Type: r.GetActionType(), Status: r.GetStatus(), Error: r.GetError()}
+		if a.Error == "" && actionStatusFailed(a.Status) {
+			a.Error = r.GetMessage()
+		}
+		if ts := r.GetCompletedAt(); ts > 0 {
+			a.At = time.Unix(ts, 0).UTC()
+		} else if ts := r.GetStartedAt(); ts > 0 {
+			a.At = time.Unix(ts, 0).UTC()
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// actionStatusFailed reports whether an agent action status is a failure.
+func actionStatusFailed(status string) bool {
+	status = strings.ToLower(status)
+	return strings.Contains(status, "fail") || strings.Contains(status, "error")
+}
+
+func failedActions(actions []explainAction) []string {
+	var out []string
+	for _, a := range actions {
+		if !actionStatusFailed(a.Status) {
+			continue
+		}
+		desc := valueOrDash(a.Type)
+		if a.Error != "" {
+			desc += ": " + a.Error
+		}
+		out = append(out, desc)
+	}
+	return out
+}
+
+func printExplanation(ex *explanation) {
+	fmt.Printf("Workload %s (%s): %s, desired %s", ex.WorkloadID, valueOrDash(ex.Type), valueOrDash(ex.Status), valueOrDash(ex.DesiredState))
+	if ex.RevisionID != "" {
+		fmt.Printf(", revision %s", ex.RevisionID)
+	}
+	fmt.Println()
+	if ex.Node != nil {
+		fmt.Printf("Node %s: %s, %.2f/%.2f CPU cores and %d/%d MB memory available",
+			ex.Node.ID, ex.Node.Status, ex.Node.AvailableCPU, ex.Node.TotalCPU, ex.Node.AvailableMemoryMB, ex.Node.TotalMemoryMB)
+		if !ex.Node.LastHeartbeat.IsZero() {
+			fmt.Printf(", last heartbeat %s ago", time.Since(ex.Node.LastHeartbeat).Round(time.Second))
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	fmt.Println("Diagnosis:")
+	for _, f := range ex.Findings {
+		fmt.Printf("  [%s] %s\n", strings.ToUpper(f.Severity), f.Summary)
+		if f.Detail != "" {
+			fmt.Printf("      %s\n", f.Detail)
+		}
+		for _, r := range f.Remediations {
+			fmt.Printf("      - %s\n", r)
+		}
+	}
+
+	if ex.Retry != nil {
+		fmt.Println()
+		fmt.Println("Retry:")
+		fmt.Printf("  %s\n", ex.Retry.Summary)
+	}
+
+	if len(ex.RecentActions) > 0 {
+		fmt.Println()
+		fmt.Println("Recent agent actions (newest first):")
+		for _, a := range ex.RecentActions {
+			at := "-"
+			if !a.At.IsZero() {
+				at = a.At.Format(time.RFC3339)
+			}
+			line := fmt.Sprintf("  %s  %s  %s", at, valueOrDash(a.Type), valueOrDash(a.Status))
+			if a.Error != "" {
+				line += "  " + a.Error
+			}
+			fmt.Println(line)
+		}
+	}
+	if ex.ActionsError != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "\nagent action history unavailable: %s\n", ex.ActionsError)
+	}
+}
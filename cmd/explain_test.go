@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExplainActions(t *testing.T) {
+	resp := &agentv1.ListActionsResponse{Actions: []*agentv1.ActionRecord{
+		{ActionType: "pull", Status: "FAILED", StartedAt: 100, CompletedAt: 160, Error: "manifest unknown", Message: "pulling"},
+		{ActionType: "start", Status: "RUNNING", StartedAt: 200, Message: "starting container"},
+		{ActionType: "stop", Status: "SUCCEEDED"},
+		{ActionType: "delete", Status: "ERROR", Message: "volume busy"},
+	}}
+	actions := explainActions(resp)
+	want := []explainAction{
+		{Type: "pull", Status: "FAILED", At: time.Unix(160, 0).UTC(), Error: "manifest unknown"},
+		{Type: "start", Status: "RUNNING", At: time.Unix(200, 0).UTC()},
+		{Type: "stop", Status: "SUCCEEDED"},
+		{Type: "delete", Status: "ERROR", Error: "volume busy"},
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("explainActions = %+v, want %+v", actions, want)
+	}
+	if got := failedActions(actions); !reflect.DeepEqual(got, []string{"pull: manifest unknown", "delete: volume busy"}) {
+		t.Fatalf("failedActions = %v", got)
+	}
+	if got := explainActions(nil); len(got) != 0 {
+		t.Fatalf("explainActions(nil) = %v, want empty", got)
+	}
+}
+
+func TestExplainWorkload(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	readyNode := func(heartbeat time.Duration) *controlv1.NodeView {
+		return &controlv1.NodeView{NodeId: "node-1", Status: "Ready", LastHeartbeat: timestamppb.New(now.Add(-heartbeat))}
+	}
+	running := &controlv1.WorkloadView{WorkloadId: "web", Status: "Running", DesiredState: "Running", AssignedNodeId: "node-1"}
+
+	tests := []struct {
+		name     string
+		w        *controlv1.WorkloadView
+		node     *controlv1.NodeView
+		nodeErr  error
+		actions  []explainAction
+		want     []string // finding summaries, in order; substrings
+		severity []string
+	}{
+		{
+			name:     "healthy",
+			w:        running,
+			node:     readyNode(10 * time.Second),
+			want:     []string{"No problems detected."},
+			severity: []string{"info"},
+		},
+		{
+			name:     "unplaced",
+			w:        &controlv1.WorkloadView{WorkloadId: "web", Status: "Pending", DesiredState: "Running"},
+			want:     []string{"has not been placed on a node", "still converging"},
+			severity: []string{"warning", "info"},
+		},
+		{
+			name:     "node not ready",
+			w:        running,
+			node:     &controlv1.NodeView{NodeId: "node-1", Status: "NotReady", StatusReason: "heartbeat timeout", LastHeartbeat: timestamppb.New(now.Add(-30 * time.Second))},
+			want:     []string{"Assigned node node-1 is NotReady."},
+			severity: []string{"error"},
+		},
+		{
+			name:     "stale heartbeat",
+			w:        running,
+			node:     readyNode(5 * time.Minute),
+			want:     []string{"last sent a heartbeat 5m0s ago"},
+			severity: []string{"warning"},
+		},
+		{
+			name:     "node unreadable",
+			w:        running,
+			nodeErr:  errors.New("unavailable"),
+			want:     []string{"Assigned node node-1 could not be read: unavailable"},
+			severity: []string{"warning"},
+		},
+		{
+			name: "known failure reason with capacity",
+			w: &controlv1.WorkloadView{WorkloadId: "web", Status: "Failed", DesiredState: "Running", AssignedNodeId: "node-1",
+				FailureReason: "insufficient_resources", Reason: &controlv1.ReasonDetail{Code: "INSUFFICIENT_RESOURCES", Message: "no memory"}},
+			node:     &controlv1.NodeView{NodeId: "node-1", Status: "Ready", AvailableCpuCores: 0.5, TotalCpuCores: 4, AvailableMemoryMb: 128, TotalMemoryMb: 8192},
+			want:     []string{"INSUFFICIENT_RESOURCES: The node did not have enough"},
+			severity: []string{"error"},
+		},
+		{
+			name:     "unknown failure reason from reason code",
+			w:        &controlv1.WorkloadView{WorkloadId: "web", Status: "Failed", AssignedNodeId: "node-1", Reason: &controlv1.ReasonDetail{Code: "QUOTA_EXCEEDED"}},
+			node:     readyNode(0),
+			want:     []string{"Failure reason QUOTA_EXCEEDED."},
+			severity: []string{"error"},
+		},
+		{
+			name:     "failed agent actions",
+			w:        running,
+			node:     readyNode(0),
+			actions:  []explainAction{{Type: "pull", Status: "FAILED", Error: "denied"}, {Type: "start", Status: "SUCCEEDED"}},
+			want:     []string{"1 of the last 2 agent actions failed."},
+			severity: []string{"warning"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := explainWorkload(tt.w, tt.node, tt.nodeErr, tt.actions, now)
+			if len(ex.Findings) != len(tt.want) {
+				t.Fatalf("findings = %+v, want %d", ex.Findings, len(tt.want))
+			}
+			for i, f := range ex.Findings {
+				if !strings.Contains(f.Summary, tt.want[i]) || f.Severity != tt.severity[i] {
+					t.Errorf("finding %d = %s %q, want %s containing %q", i, f.Severity, f.Summary, tt.severity[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	ex := explainWorkload(&controlv1.WorkloadView{WorkloadId: "web", Status: "Failed", AssignedNodeId: "node-1",
+		FailureReason: "INSUFFICIENT_RESOURCES", Reason: &controlv1.ReasonDetail{Message: "no memory"}},
+		&controlv1.NodeView{NodeId: "node-1", Status: "Ready", AvailableCpuCores: 0.5, TotalCpuCores: 4, AvailableMemoryMb: 128, TotalMemoryMb: 8192},
+		nil, nil, now)
+	if want := "no memory Node node-1 has 0.50 of 4.00 CPU cores and 128 of 8192 MB memory available."; ex.Findings[0].Detail != want {
+		t.Errorf("capacity detail = %q, want %q", ex.Findings[0].Detail, want)
+	}
+	if ex.Node == nil || ex.Node.ID != "node-1" || ex.Node.TotalMemoryMB != 8192 {
+		t.Errorf("node = %+v", ex.Node)
+	}
+}
+
+func TestExplainRetryState(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	retryable := func(ok bool) *controlv1.ReasonDetail {
+		return &controlv1.ReasonDetail{Code: "RUNTIME_ERROR", Retryable: ok}
+	}
+	tests := []struct {
+		name string
+		w    *controlv1.WorkloadView
+		want string // empty means no retry state
+	}{
+		{
+			name: "never retried",
+			w:    &controlv1.WorkloadView{WorkloadId: "web"},
+		},
+		{
+			name: "non-retryable",
+			w:    &controlv1.WorkloadView{WorkloadId: "web", RetryAttempts: 1, RetryMaxAttempts: 5, Reason: retryable(false)},
+			want: "The scheduler will not retry this failure automatically; fix the cause, then run: persysctl workload retry --id web",
+		},
+		{
+			name: "retries exhausted",
+			w: &controlv1.WorkloadView{WorkloadId: "web", RetryAttempts: 5, RetryMaxAttempts: 5, Reason: retryable(true),
+				RetryNextAt: timestamppb.New(now.Add(time.Minute))},
+			want: "Automatic retries are exhausted (5/5); after fixing the cause run: persysctl workload retry --id web",
+		},
+		{
+			name: "future retry",
+			w:    &controlv1.WorkloadView{WorkloadId: "web", RetryAttempts: 2, RetryMaxAttempts: 5, RetryNextAt: timestamppb.New(now.Add(90 * time.Second))},
+			want: "Attempt 3 of 5; next automatic retry in 1m30s (2025-06-01T12:01:30Z).",
+		},
+		{
+			name: "future retry from reason",
+			w: &controlv1.WorkloadView{WorkloadId: "web", RetryAttempts: 1, RetryMaxAttempts: 3,
+				Reason: &controlv1.ReasonDetail{Code: "RUNTIME_ERROR", Retryable: true, NextRetryAt: timestamppb.New(now.Add(10 * time.Second))}},
+			want: "Attempt 2 of 3; next automatic retry in 10s (2025-06-01T12:00:10Z).",
+		},
+		{
+			name: "retry due",
+			w:    &controlv1.WorkloadView{WorkloadId: "web", RetryAttempts: 2, RetryMaxAttempts: 5, RetryNextAt: timestamppb.New(now.Add(-time.Minute))},
+			want: "A retry was due at 2025-06-01T11:59:00Z and should be in progress.",
+		},
+		{
+			name: "attempts only",
+			w:    &controlv1.WorkloadView{WorkloadId: "web", RetryAttempts: 1, RetryMaxAttempts: 3},
+			want: "1 of 3 retry attempts used.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := explainRetryState(tt.w, now)
+			if tt.want == "" {
+				if r != nil {
+					t.Fatalf("explainRetryState = %+v, want nil", r)
+				}
+				return
+			}
+			if r == nil {
+				t.Fatalf("explainRetryState = nil, want %q", tt.want)
+			}
+			if r.Summary != tt.want {
+				t.Errorf("summary = %q, want %q", r.Summary, tt.want)
+			}
+		})
+	}
+
+	r := explainRetryState(&controlv1.WorkloadView{RetryAttempts: 1, Reason: retryable(false)}, now)
+	if r.Retryable == nil || *r.Retryable {
+		t.Errorf("retryable = %v, want false", r.Retryable)
+	}
+	if r := explainRetryState(&controlv1.WorkloadView{RetryAttempts: 1}, now); r.Retryable != nil {
+		t.Errorf("retryable without a reason = %v, want unset", *r.Retryable)
+	}
+}
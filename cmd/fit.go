@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workloadFitSpecFile string
+	workloadFitType     string
+	workloadFitSelector map[string]string
+	workloadFitOutput   string
+)
+
+// fitRequirements is what a spec asks of a node.
+type fitRequirements struct {
+	Type          string            `json:"type"`
+	CPUMillicores int64             `json:"cpuMillicores"`
+	MemoryMB      int64             `json:"memoryMb"`
+	DiskGB        int64             `json:"diskGb,omitempty"`
+	NodeSelector  map[string]string `json:"nodeSelector,omitempty"`
+	VolumeDrivers []string          `json:"volumeDrivers,omitempty"`
+	StoragePools  []string          `json:"storagePools,omitempty"`
+}
+
+type nodeFit struct {
+	NodeID            string   `json:"nodeId"`
+	Status            string   `json:"status"`
+	Feasible          bool     `json:"feasible"`
+	AvailableCPU      float64  `json:"availableCpuCores"`
+	AvailableMemoryMB int64    `json:"availableMemoryMb"`
+	Reasons           []string `json:"reasons,omitempty"`
+}
+
+type fitReport struct {
+	Requirements fitRequirements `json:"requirements"`
+	Feasible     []nodeFit       `json:"feasible"`
+	Rejected     []nodeFit       `json:"rejected"`
+	Unverified   []string        `json:"unverified,omitempty"`
+}
+
+var workloadFitCmd = &cobra.Command{
+	Use:   "fit",
+	Short: "Preview which nodes could run a workload spec",
+	Long: `Evaluates a spec's resource requests, workload type, node selector and managed
+volume drivers against every node reported by the scheduler, and lists the
+feasible nodes and why the others were rejected. Nothing is applied.
+
+The node selector is read from the spec's "nodeSelector" field and merged with
+--node-selector. The scheduler's workload spec has no node selector, so it is
+not enforced at placement; matching nodes are listed as unverified instead of
+rejecting the others. The command exits non-zero when no node fits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		body, err := readSpecFile(workloadFitSpecFile)
+		cobra.CheckErr(err)
+		spec, err := parseSchedulerWorkloadSpec(workloadFitType, body)
+		cobra.CheckErr(err)
+		req := fitRequirementsFor(spec, specNodeSelector(body))
+		for k, v := range workloadFitSelector {
+			if req.NodeSelector == nil {
+				req.NodeSelector = map[string]string{}
+			}
+			req.NodeSelector[k] = v
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		resp, err := c.SchedulerListNodes("")
+		cobra.CheckErr(err)
+
+		report := buildFitReport(req, resp.GetNodes())
+		if strings.EqualFold(workloadFitOutput, "json") {
+			data, err := json.MarshalIndent(report, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+		} else {
+			printFitReport(report)
+		}
+		if len(report.Feasible) == 0 {
+			cobra.CheckErr(fmt.Errorf("no node can run this spec (%d rejected)", len(report.Rejected)))
+		}
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadFitCmd)
+	workloadFitCmd.Flags().StringVarP(&workloadFitSpecFile, "file", "f", "", "Path to JSON spec file")
+	workloadFitCmd.Flags().StringVar(&workloadFitType, "type", "container", "Workload type: container|compose|vm")
+	workloadFitCmd.Flags().StringToStringVar(&workloadFitSelector, "node-selector", nil, "Additional node label constraints key=value")
+	workloadFitCmd.Flags().StringVarP(&workloadFitOutput, "output", "o", "table", "Output format: table|json")
+	addSpecRenderFlags(workloadFitCmd)
+	cobra.CheckErr(workloadFitCmd.MarkFlagRequired("file"))
+}
+
+// specNodeSelector reads the node selector from a spec body. The scheduler
+// spec has no selector field, so it is taken from the raw document.
+func specNodeSelector(body []byte) map[string]string {
+	var doc struct {
+		NodeSelector      map[string]string `json:"nodeSelector"`
+		NodeSelectorSnake map[string]string `json:"node_selector"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	if len(doc.NodeSelector) > 0 {
+		return doc.NodeSelector
+	}
+	return doc.NodeSelectorSnake
+}
+
+func fitRequirementsFor(spec *controlv1.WorkloadSpec, selector map[string]string) fitRequirements {
+	req := fitRequirements{
+		Type:          spec.GetType(),
+		CPUMillicores: spec.GetResources().GetCpuMillicores(),
+		MemoryMB:      spec.GetResources().GetMemoryMb(),
+		DiskGB:        spec.GetResources().GetDiskGb(),
+		NodeSelector:  selector,
+	}
+	var volumes []*controlv1.ManagedVolumeSpec
+	switch {
+	case spec.GetContainer() != nil:
+		volumes = spec.GetContainer().GetManagedVolumes()
+	case spec.GetVm() != nil:
+		vm := spec.GetVm()
+		req.CPUMillicores = max(req.CPUMillicores, int64(vm.GetVcpus())*1000)
+		req.MemoryMB = max(req.MemoryMB, vm.GetMemoryMb())
+		volumes = vm.GetManagedVolumes()
+		for _, d := range vm.GetDisks() {
+			if d.GetPoolName() != "" && !slices.Contains(req.StoragePools, d.GetPoolName()) {
+				req.StoragePools = append(req.StoragePools, d.GetPoolName())
+			}
+		}
+	}
+	for _, v := range volumes {
+		driver := strings.ToLower(strings.TrimSpace(v.GetDriver()))
+		if driver == "" {
+			driver = "local"
+		}
+		if !slices.Contains(req.VolumeDrivers, driver) {
+			req.VolumeDrivers = append(req.VolumeDrivers, driver)
+		}
+	}
+	sort.Strings(req.VolumeDrivers)
+	sort.Strings(req.StoragePools)
+	return req
+}
+
+// evaluateNodeFit returns the reasons a node cannot run the requirements;
+// none means it fits. Only constraints the scheduler reports per node and
+// enforces at placement are checked here.
+func evaluateNodeFit(req fitRequirements, n *controlv1.NodeView) []string {
+	var reasons []string
+	if !strings.EqualFold(n.GetStatus(), "Ready") {
+		reason := fmt.Sprintf("node is %s", valueOrDash(n.GetStatus()))
+		if n.GetStatusReason() != "" {
+			reason += ": " + n.GetStatusReason()
+		}
+		reasons = append(reasons, reason)
+	}
+	if types := n.GetSupportedWorkloadTypes(); len(types) > 0 && req.Type != "" &&
+		!slices.ContainsFunc(types, func(t string) bool { return strings.EqualFold(t, req.Type) }) {
+		reasons = append(reasons, fmt.Sprintf("does not support %s workloads (supports %s)", req.Type, strings.Join(types, ",")))
+	}
+	if avail := int64(n.GetAvailableCpuCores() * 1000); req.CPUMillicores > avail {
+		reasons = append(reasons, fmt.Sprintf("insufficient cpu: requests %dm, %dm available", req.CPUMillicores, avail))
+	}
+	if req.MemoryMB > n.GetAvailableMemoryMb() {
+		reasons = append(reasons, fmt.Sprintf("insufficient memory: requests %dMi, %dMi available", req.MemoryMB, n.GetAvailableMemoryMb()))
+	}
+	return reasons
+}
+
+func buildFitReport(req fitRequirements, nodes []*controlv1.NodeView) fitReport {
+	report := fitReport{Requirements: req, Feasible: []nodeFit{}, Rejected: []nodeFit{}}
+	for _, n := range nodes {
+		fit := nodeFit{
+			NodeID:            n.GetNodeId(),
+			Status:            n.GetStatus(),
+			AvailableCPU:      n.GetAvailableCpuCores(),
+			AvailableMemoryMB: n.GetAvailableMemoryMb(),
+			Reasons:           evaluateNodeFit(req, n),
+		}
+		fit.Feasible = len(fit.Reasons) == 0
+		if fit.Feasible {
+			report.Feasible = append(report.Feasible, fit)
+		} else {
+			report.Rejected = append(report.Rejected, fit)
+		}
+	}
+	// Most free capacity first, the order a least-allocated scheduler would prefer.
+	sort.Slice(report.Feasible, func(i, j int) bool {
+		a, b := report.Feasible[i], report.Feasible[j]
+		if a.AvailableCPU != b.AvailableCPU {
+			return a.AvailableCPU > b.AvailableCPU
+		}
+		if a.AvailableMemoryMB != b.AvailableMemoryMB {
+			return a.AvailableMemoryMB > b.AvailableMemoryMB
+		}
+		return a.NodeID < b.NodeID
+	})
+	sort.Slice(report.Rejected, func(i, j int) bool { return report.Rejected[i].NodeID < report.Rejected[j].NodeID })
+
+	// NodeView does not carry storage capabilities; say so rather than
+	// silently passing those constraints.
+	if len(req.VolumeDrivers) > 0 {
+		report.Unverified = append(report.Unverified, fmt.Sprintf("managed volume drivers (%s): the scheduler does not report node storage drivers", strings.Join(req.VolumeDrivers, ",")))
+	}
+	if len(req.StoragePools) > 0 {
+		report.Unverified = append(report.Unverified, fmt.Sprintf("storage pools (%s): the scheduler does not report node storage pools", strings.Join(req.StoragePools, ",")))
+	}
+	// The selector never reaches the scheduler, so it cannot reject nodes.
+	if len(req.NodeSelector) > 0 {
+		labels := make(map[string]map[string]string, len(nodes))
+		for _, n := range nodes {
+			labels[n.GetNodeId()] = n.GetLabels()
+		}
+		var matching []string
+		for _, f := range report.Feasible {
+			if selectorMatches(req.NodeSelector, labels[f.NodeID]) {
+				matching = append(matching, f.NodeID)
+			}
+		}
+		report.Unverified = append(report.Unverified, fmt.Sprintf("node selector %s (feasible nodes with matching labels: %s): the scheduler spec has no node selector, so placement does not enforce it",
+			formatSelector(req.NodeSelector), valueOrDash(strings.Join(matching, ","))))
+	}
+	if req.DiskGB > 0 {
+		report.Unverified = append(report.Unverified, fmt.Sprintf("disk request %dGi: the scheduler does not report node disk capacity", req.DiskGB))
+	}
+	return report
+}
+
+// selectorMatches reports whether labels contain every selector pair.
+func selectorMatches(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func formatSelector(selector map[string]string) string {
+	pairs := make([]string, 0, len(selector))
+	for _, k := range slices.Sorted(maps.Keys(selector)) {
+		pairs = append(pairs, k+"="+selector[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func printFitReport(r fitReport) {
+	req := r.Requirements
+	fmt.Printf("Spec: type=%s cpu=%dm memory=%dMi", valueOrDash(req.Type), req.CPUMillicores, req.MemoryMB)
+	if len(req.NodeSelector) > 0 {
+		fmt.Printf(" selector=%s", formatSelector(req.NodeSelector))
+	}
+	fmt.Println()
+	fmt.Printf("%d feasible, %d rejected\n\n", len(r.Feasible), len(r.Rejected))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NODE\tSTATUS\tFIT\tCPU FREE\tMEM FREE(Mi)\tREASONS")
+	for _, f := range append(append([]nodeFit{}, r.Feasible...), r.Rejected...) {
+		fit := "no"
+		if f.Feasible {
+			fit = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\t%d\t%s\n", f.NodeID, valueOrDash(f.Status), fit,
+			f.AvailableCPU, f.AvailableMemoryMB, valueOrDash(strings.Join(f.Reasons, "; ")))
+	}
+	cobra.CheckErr(tw.Flush())
+
+	for _, u := range r.Unverified {
+		fmt.Printf("\nnot checked: %s", u)
+	}
+	if len(r.Unverified) > 0 {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+)
+
+func TestEvaluateNodeFit(t *testing.T) {
+	ready := &controlv1.NodeView{
+		NodeId: "n1", Status: "Ready", AvailableCpuCores: 2, AvailableMemoryMb: 4096,
+		SupportedWorkloadTypes: []string{"container", "compose"}, Labels: map[string]string{"zone": "eu-1"},
+	}
+	tests := []struct {
+		name string
+		req  fitRequirements
+		node *controlv1.NodeView
+		want []string
+	}{
+		{"fits", fitRequirements{Type: "Container", CPUMillicores: 2000, MemoryMB: 4096}, ready, nil},
+		{"no supported types reported", fitRequirements{Type: "vm"}, &controlv1.NodeView{Status: "ready"}, nil},
+		{"unsupported type", fitRequirements{Type: "vm"}, ready, []string{"does not support vm workloads (supports container,compose)"}},
+		{"insufficient resources", fitRequirements{CPUMillicores: 2500, MemoryMB: 8192}, ready, []string{
+			"insufficient cpu: requests 2500m, 2000m available",
+			"insufficient memory: requests 8192Mi, 4096Mi available",
+		}},
+		{"not ready", fitRequirements{}, &controlv1.NodeView{Status: "NotReady", StatusReason: "heartbeat missed"}, []string{"node is NotReady: heartbeat missed"}},
+		{"selector is not enforced", fitRequirements{NodeSelector: map[string]string{"zone": "us-1"}}, ready, nil},
+	}
+	for _, tt := range tests {
+		if got := evaluateNodeFit(tt.req, tt.node); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: evaluateNodeFit = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildFitReportSelectorUnverified(t *testing.T) {
+	nodes := []*controlv1.NodeView{
+		{NodeId: "a", Status: "Ready", AvailableCpuCores: 1, Labels: map[string]string{"zone": "eu-1"}},
+		{NodeId: "b", Status: "Ready", AvailableCpuCores: 4},
+		{NodeId: "c", Status: "Ready", AvailableCpuCores: 8, Labels: map[string]string{"zone": "eu-1"}},
+	}
+	report := buildFitReport(fitRequirements{NodeSelector: map[string]string{"zone": "eu-1"}}, nodes)
+	if len(report.Feasible) != 3 || len(report.Rejected) != 0 {
+		t.Fatalf("selector rejected nodes: %+v", report)
+	}
+	if len(report.Unverified) != 1 || !strings.Contains(report.Unverified[0], "zone=eu-1 (feasible nodes with matching labels: c,a)") {
+		t.Fatalf("Unverified = %q", report.Unverified)
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/config"
+	"github.com/persys-dev/persysctl/internal/history"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	workloadHistoryID       string
+	workloadHistoryRevision string
+	workloadRollbackID      string
+	workloadRollbackTo      string
+	workloadRollbackDesired string
+)
+
+var workloadHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List revisions applied to a workload from this machine",
+	Run: func(cmd *cobra.Command, args []string) {
+		store := history.NewStore(config.GetConfig().HistoryDir)
+		if workloadHistoryRevision != "" {
+			entry, err := store.Get(workloadHistoryID, workloadHistoryRevision)
+			cobra.CheckErr(err)
+			data, err := json.MarshalIndent(entry, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+
+		entries, err := store.List(workloadHistoryID)
+		if errors.Is(err, history.ErrNotFound) {
+			entries, err = nil, nil
+		}
+		cobra.CheckErr(err)
+		out := make([]map[string]any, 0, len(entries))
+		for i, e := range entries {
+			item := map[string]any{
+				"revisionId": e.RevisionID,
+				"appliedAt":  e.AppliedAt.UTC().Format(time.RFC3339),
+			}
+			if e.Type != "" {
+				item["type"] = e.Type
+			}
+			if e.DesiredState != "" {
+				item["desiredState"] = e.DesiredState
+			}
+			if e.Transport != "" {
+				item["transport"] = e.Transport
+			}
+			if i == len(entries)-1 {
+				item["current"] = true
+			}
+			out = append(out, item)
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+var workloadRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Re-apply a previously applied revision of a workload",
+	Run: func(cmd *cobra.Command, args []string) {
+		store := history.NewStore(config.GetConfig().HistoryDir)
+		var (
+			entry *history.Entry
+			err   error
+		)
+		if workloadRollbackTo == "" {
+			entry, err = store.Previous(workloadRollbackID)
+		} else {
+			entry, err = store.Get(workloadRollbackID, workloadRollbackTo)
+		}
+		cobra.CheckErr(err)
+
+		spec := &controlv1.WorkloadSpec{}
+		cobra.CheckErr(protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(entry.Spec, spec))
+		desired := entry.DesiredState
+		if workloadRollbackDesired != "" {
+			desired = normalizeDesiredState(workloadRollbackDesired)
+		}
+		if desired == "" {
+			desired = "Running"
+		}
+		req := &controlv1.ApplyWorkloadRequest{
+			WorkloadId:   entry.WorkloadID,
+			RevisionId:   entry.RevisionID,
+			DesiredState: desired,
+			Spec:         spec,
+		}
+
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		if mode == "client" {
+			cobra.CheckErr(printProtoAs(req, dryRunOutput))
+			return
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		if mode == "server" {
+			resp, err := c.PreviewSchedulerWorkload(req)
+			cobra.CheckErr(err)
+			cobra.CheckErr(printProtoAs(resp, dryRunOutput))
+			return
+		}
+
+		fromRevision := ""
+		if getResp, err := c.GetWorkload(entry.WorkloadID); err == nil && getResp.GetWorkload() != nil {
+			fromRevision = getResp.GetWorkload().GetRevisionId()
+		}
+		resp, err := c.ApplySchedulerWorkload(req)
+		cobra.CheckErr(err)
+		if resp.GetSuccess() {
+			c.RecordHistory(req)
+		}
+		out := map[string]any{
+			"workload_id": entry.WorkloadID,
+			"revision_id": entry.RevisionID,
+			"accepted":    resp.GetSuccess(),
+		}
+		if fromRevision != "" {
+			out["from_revision_id"] = fromRevision
+		}
+		if !resp.GetSuccess() {
+			out["error_message"] = resp.GetErrorMessage()
+			out["failure_reason"] = resp.GetFailureReason().String()
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadHistoryCmd)
+	workloadCmd.AddCommand(workloadRollbackCmd)
+
+	workloadHistoryCmd.Flags().StringVar(&workloadHistoryID, "id", "", "Workload ID")
+	workloadHistoryCmd.Flags().StringVar(&workloadHistoryRevision, "revision", "", "Show the full entry, including the spec, for this revision")
+	cobra.CheckErr(workloadHistoryCmd.MarkFlagRequired("id"))
+
+	workloadRollbackCmd.Flags().StringVar(&workloadRollbackID, "id", "", "Workload ID")
+	workloadRollbackCmd.Flags().StringVar(&workloadRollbackTo, "to-revision", "", "Revision to re-apply (default: the revision before the current one)")
+	workloadRollbackCmd.Flags().StringVar(&workloadRollbackDesired, "desired-state", "", "Override desired state: running|stopped (default: as recorded)")
+	cobra.CheckErr(workloadRollbackCmd.MarkFlagRequired("id"))
+	addDryRunFlags(workloadRollbackCmd)
+}
+
+// revisionFor returns the explicit --revision value, or a content-hash
+// revision of spec when none was given.
+func revisionFor(revision string, spec proto.Message) string {
+	if strings.TrimSpace(revision) != "" {
+		return strings.TrimSpace(revision)
+	}
+	return client.ContentRevision(spec)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/agentstream"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workloadLogsFollow     bool
+	workloadLogsSince      string
+	workloadLogsTail       int64
+	workloadLogsTimestamps bool
+)
+
+var workloadLogsCmd = &cobra.Command{
+	Use:   "logs <id>",
+	Short: "Print or stream workload logs (experimental; VM workloads: serial console log)",
+	Long: `Prints or streams workload logs. VM workloads return their serial
+console log.
+
+Experimental: neither the agent log stream (persysctl.experimental.v1.WorkloadStreams)
+nor the gateway route GET /workloads/{id}/logs is part of the persys-cloud
+APIs yet; agents and gateways without them are reported as unsupported.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseSince(workloadLogsSince)
+		cobra.CheckErr(err)
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		req := agentstream.LogsRequest{
+			WorkloadID: args[0],
+			Follow:     workloadLogsFollow,
+			Since:      since,
+			TailLines:  workloadLogsTail,
+			Timestamps: workloadLogsTimestamps,
+		}
+		err = c.StreamWorkloadLogs(ctx, req, func(chunk *agentstream.LogChunk) error {
+			out := os.Stdout
+			if chunk.Stream == "stderr" {
+				out = os.Stderr
+			}
+			if workloadLogsTimestamps && !chunk.Timestamp.IsZero() {
+				_, _ = fmt.Fprintf(out, "%s ", chunk.Timestamp.UTC().Format(time.RFC3339Nano))
+			}
+			_, err := out.Write(chunk.Data)
+			return err
+		})
+		if errors.Is(err, agentstream.ErrUnsupported) {
+			cobra.CheckErr(fmt.Errorf("log streaming is not available for workload %s: %w", args[0], err))
+		}
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadLogsCmd)
+
+	workloadLogsCmd.Flags().BoolVarP(&workloadLogsFollow, "follow", "f", false, "Keep streaming new output")
+	workloadLogsCmd.Flags().StringVar(&workloadLogsSince, "since", "", "Only output newer than a duration (10m) or RFC3339 time")
+	workloadLogsCmd.Flags().Int64Var(&workloadLogsTail, "tail", -1, "Lines of recent output to show (-1 = all)")
+	workloadLogsCmd.Flags().BoolVar(&workloadLogsTimestamps, "timestamps", false, "Prefix each chunk with its timestamp")
+}
+
+// parseSince accepts a relative duration ("15m") or an RFC3339 timestamp.
+func parseSince(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (expected a duration like 10m or an RFC3339 time)", v)
+	}
+	return t, nil
+}
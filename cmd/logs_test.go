@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	if got, err := parseSince("  "); err != nil || !got.IsZero() {
+		t.Errorf("parseSince(blank) = %v, %v; want zero time", got, err)
+	}
+
+	before := time.Now()
+	got, err := parseSince("15m")
+	if err != nil {
+		t.Fatalf("parseSince(15m): %v", err)
+	}
+	if want := before.Add(-15 * time.Minute); got.Before(want.Add(-time.Second)) || got.After(time.Now().Add(-15*time.Minute)) {
+		t.Errorf("parseSince(15m) = %v, want about %v", got, want)
+	}
+
+	got, err = parseSince("2026-10-18T09:30:00+02:00")
+	if err != nil || !got.Equal(time.Date(2026, 10, 18, 7, 30, 0, 0, time.UTC)) {
+		t.Errorf("parseSince(RFC3339) = %v, %v", got, err)
+	}
+
+	for _, v := range []string{"yesterday", "2026-10-18", "15"} {
+		if _, err := parseSince(v); err == nil || !strings.Contains(err.Error(), "--since") {
+			t.Errorf("parseSince(%q) error = %v, want invalid --since", v, err)
+		}
+	}
+}
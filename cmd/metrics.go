@@ -3,20 +3,70 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/persys-dev/persysctl/internal/config"
+	"github.com/persys-dev/persysctl/internal/history"
 	"github.com/spf13/cobra"
 )
 
+var (
+	metricsHistory bool
+	metricsLimit   int
+	metricsRecord  bool
+)
+
 var metricsCmd = &cobra.Command{
 	Use:   "metrics",
 	Short: "View Persys Compute metrics",
-	Long:  `Retrieves node and workload metrics from Persys Compute.`,
+	Long: `Retrieves node and workload metrics from Persys Compute.
+
+With --record the result is also stored as a local snapshot (under
+history_dir), so --history can show how counts such as failed workloads change
+across recorded runs.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		c, _, err := newClientWithTrace()
+		cfg := config.GetConfig()
+		store := history.NewStore(cfg.HistoryDir)
+		if metricsHistory {
+			snaps, err := store.ListMetrics(metricsTarget(cfg))
+			cobra.CheckErr(err)
+			if metricsLimit > 0 && len(snaps) > metricsLimit {
+				snaps = snaps[len(snaps)-metricsLimit:]
+			}
+			out := make([]map[string]any, 0, len(snaps))
+			for i, snap := range snaps {
+				item := map[string]any{
+					"recordedAt": snap.RecordedAt,
+					"metrics":    snap.Metrics,
+				}
+				if i > 0 {
+					prev := snaps[i-1].Metrics
+					item["delta"] = map[string]int{
+						"readyNodes":       snap.Metrics.ReadyNodes - prev.ReadyNodes,
+						"notReadyNodes":    snap.Metrics.NotReadyNodes - prev.NotReadyNodes,
+						"runningWorkloads": snap.Metrics.RunningWorkloads - prev.RunningWorkloads,
+						"pendingWorkloads": snap.Metrics.PendingWorkloads - prev.PendingWorkloads,
+						"failedWorkloads":  snap.Metrics.FailedWorkloads - prev.FailedWorkloads,
+					}
+				}
+				out = append(out, item)
+			}
+			data, err := json.MarshalIndent(out, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+
+		c, cfg, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
 		metrics, err := c.GetMetrics()
 		cobra.CheckErr(err)
+		if metricsRecord {
+			if err := store.RecordMetrics(history.MetricsSnapshot{Target: metricsTarget(cfg), Metrics: *metrics}); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "warning: failed to record metrics snapshot: %v\n", err)
+			}
+		}
 		data, err := json.MarshalIndent(metrics, "", "  ")
 		cobra.CheckErr(err)
 		fmt.Println(string(data))
@@ -25,4 +75,16 @@ var metricsCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.Flags().BoolVar(&metricsHistory, "history", false, "Show locally recorded snapshots for the current endpoint instead of querying")
+	metricsCmd.Flags().IntVar(&metricsLimit, "limit", 0, "With --history, show only the newest N snapshots")
+	metricsCmd.Flags().BoolVar(&metricsRecord, "record", false, "Record the result as a local snapshot")
+}
+
+// metricsTarget identifies the endpoint a snapshot came from, so snapshots
+// from different clusters are not compared.
+func metricsTarget(cfg config.Config) string {
+	if cfg.Transport == "grpc" {
+		return "grpc://" + cfg.GRPCEndpoint
+	}
+	return cfg.APIEndpoint
 }
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsServeListen   string
+	metricsServeInterval time.Duration
+	metricsServeCluster  string
+)
+
+var metricsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Expose cluster, node and workload metrics in Prometheus text format",
+	Long: `Periodically polls the cluster summary, nodes and workload usage and serves
+them on /metrics in the Prometheus text exposition format.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		exp := &metricsExporter{client: c, cluster: metricsServeCluster}
+		exp.poll()
+		go func() {
+			ticker := time.NewTicker(metricsServeInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					exp.poll()
+				}
+			}
+		}()
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exp)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = fmt.Fprintln(w, `persysctl metrics exporter: see /metrics`)
+		})
+		srv := &http.Server{Addr: metricsServeListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+		_, _ = fmt.Fprintf(os.Stderr, "Serving metrics on %s/metrics (polling every %s)\n", metricsServeListen, metricsServeInterval)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			cobra.CheckErr(err)
+		}
+	},
+}
+
+func init() {
+	metricsCmd.AddCommand(metricsServeCmd)
+
+	metricsServeCmd.Flags().StringVar(&metricsServeListen, "listen", ":9300", "Address to serve /metrics on")
+	metricsServeCmd.Flags().DurationVar(&metricsServeInterval, "interval", 15*time.Second, "How often to poll the scheduler")
+	metricsServeCmd.Flags().StringVar(&metricsServeCluster, "cluster", "default", "Value of the cluster label on every series")
+}
+
+// metricsExporter polls through the client and caches the rendered
+// exposition, so scrapes never wait on the scheduler.
+type metricsExporter struct {
+	client  *client.Client
+	cluster string
+
+	mu   sync.RWMutex
+	body []byte
+}
+
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	body := e.body
+	e.mu.RUnlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(body)
+}
+
+// poll collects one snapshot. A failing source is reported through
+// persys_exporter_source_up and does not hide the others.
+func (e *metricsExporter) poll() {
+	start := time.Now()
+	m := &promWriter{}
+	cluster := promLabel{"cluster", e.cluster}
+	sourceUp := func(source string, err error) {
+		v := 1.0
+		if err != nil {
+			v = 0
+			_, _ = fmt.Fprintf(os.Stderr, "metrics: %s: %v\n", source, err)
+		}
+		m.sample("persys_exporter_source_up", "gauge", "Whether the last poll of a source succeeded.", v, cluster, promLabel{"source", source})
+	}
+
+	summary, err := e.client.GetClusterSummary()
+	sourceUp("summary", err)
+	if err == nil {
+		const nodesHelp = "Nodes known to the scheduler by readiness."
+		m.sample("persys_cluster_nodes", "gauge", nodesHelp, float64(summary.GetReadyNodes()), cluster, promLabel{"state", "ready"})
+		m.sample("persys_cluster_nodes", "gauge", nodesHelp, float64(summary.GetNotReadyNodes()), cluster, promLabel{"state", "not_ready"})
+		const workloadsHelp = "Workloads known to the scheduler by state."
+		for _, s := range []struct {
+			state string
+			n     int32
+		}{
+			{"running", summary.GetRunningWorkloads()},
+			{"pending", summary.GetPendingWorkloads()},
+			{"failed", summary.GetFailedWorkloads()},
+			{"deleted", summary.GetDeletedWorkloads()},
+		} {
+			m.sample("persys_cluster_workloads", "gauge", workloadsHelp, float64(s.n), cluster, promLabel{"state", s.state})
+		}
+		m.sample("persys_cluster_workloads_count", "gauge", "Total workloads known to the scheduler.", float64(summary.GetTotalWorkloads()), cluster)
+	}
+
+	nodes, err := e.client.ListNodes("")
+	sourceUp("nodes", err)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+	for _, n := range nodes {
+		node := promLabel{"node", n.NodeID}
+		m.sample("persys_node_status", "gauge", "Node status as reported by the scheduler (always 1).", 1, cluster, node, promLabel{"status", n.Status})
+		m.sample("persys_node_cpu_millicores", "gauge", "Total node CPU in millicores.", float64(n.Resources.CPU), cluster, node)
+		m.sample("persys_node_cpu_available_millicores", "gauge", "Unallocated node CPU in millicores.", float64(n.Available.CPU), cluster, node)
+		m.sample("persys_node_memory_bytes", "gauge", "Total node memory in bytes.", float64(n.Resources.Memory)*1024*1024, cluster, node)
+		m.sample("persys_node_memory_available_bytes", "gauge", "Unallocated node memory in bytes.", float64(n.Available.Memory)*1024*1024, cluster, node)
+		if !n.LastHeartbeat.IsZero() {
+			m.sample("persys_node_last_heartbeat_timestamp_seconds", "gauge", "Unix time of the node's last heartbeat.", float64(n.LastHeartbeat.Unix()), cluster, node)
+		}
+	}
+
+	workloads, err := e.client.ListWorkloads("", "")
+	sourceUp("workloads", err)
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].ID < workloads[j].ID })
+	for _, w := range workloads {
+		labels := []promLabel{cluster, {"workload", w.ID}, {"type", w.Type}, {"node", w.NodeID}}
+		m.sample("persys_workload_status", "gauge", "Workload status as reported by the scheduler (always 1).", 1, append(labels, promLabel{"status", w.Status})...)
+		if w.Usage == nil {
+			continue
+		}
+		u := w.Usage
+		m.sample("persys_workload_cpu_percent", "gauge", "Workload CPU usage in percent of one core.", u.CPUPercent, labels...)
+		m.sample("persys_workload_memory_bytes", "gauge", "Workload memory usage in bytes.", float64(u.MemoryBytes), labels...)
+		m.sample("persys_workload_disk_read_bytes_total", "counter", "Bytes read from disk by the workload.", float64(u.DiskReadBytes), labels...)
+		m.sample("persys_workload_disk_write_bytes_total", "counter", "Bytes written to disk by the workload.", float64(u.DiskWriteBytes), labels...)
+		m.sample("persys_workload_network_receive_bytes_total", "counter", "Bytes received by the workload.", float64(u.NetRXBytes), labels...)
+		m.sample("persys_workload_network_transmit_bytes_total", "counter", "Bytes transmitted by the workload.", float64(u.NetTXBytes), labels...)
+		if !u.CollectedAt.IsZero() {
+			m.sample("persys_workload_usage_collected_timestamp_seconds", "gauge", "Unix time the usage snapshot was collected.", float64(u.CollectedAt.Unix()), labels...)
+		}
+	}
+
+	m.sample("persys_exporter_poll_duration_seconds", "gauge", "Duration of the last poll.", time.Since(start).Seconds(), cluster)
+	m.sample("persys_exporter_last_poll_timestamp_seconds", "gauge", "Unix time of the last poll.", float64(time.Now().Unix()), cluster)
+
+	e.mu.Lock()
+	e.body = m.bytes()
+	e.mu.Unlock()
+}
+
+type promLabel struct {
+	name  string
+	value string
+}
+
+// promWriter renders samples in the text exposition format, grouping samples
+// of one metric under a single HELP/TYPE header.
+type promWriter struct {
+	order   []string
+	headers map[string]string
+	samples map[string][]string
+}
+
+func (p *promWriter) sample(name, typ, help string, value float64, labels ...promLabel) {
+	if p.headers == nil {
+		p.headers = map[string]string{}
+		p.samples = map[string][]string{}
+	}
+	if _, ok := p.headers[name]; !ok {
+		p.order = append(p.order, name)
+		p.headers[name] = fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", l.name, promEscape(l.value))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(&b, " %g\n", value)
+	p.samples[name] = append(p.samples[name], b.String())
+}
+
+func (p *promWriter) bytes() []byte {
+	var buf bytes.Buffer
+	for _, name := range p.order {
+		buf.WriteString(p.headers[name])
+		for _, s := range p.samples[name] {
+			buf.WriteString(s)
+		}
+	}
+	return buf.Bytes()
+}
+
+func promEscape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
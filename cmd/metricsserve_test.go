@@ -0,0 +1,47 @@
+package cmd
+
+import "testing"
+
+func TestPromWriterExposition(t *testing.T) {
+	m := &promWriter{}
+	cluster := promLabel{"cluster", "prod"}
+	m.sample("persys_cluster_nodes", "gauge", "Nodes by state.", 3, cluster, promLabel{"state", "ready"})
+	m.sample("persys_workload_cpu_percent", "gauge", "CPU usage.", 12.5, cluster, promLabel{"workload", `we"b\1`})
+	// A later sample of an earlier metric is grouped under its header.
+	m.sample("persys_cluster_nodes", "gauge", "Nodes by state.", 0, cluster, promLabel{"state", "not_ready"})
+	m.sample("persys_workload_disk_read_bytes_total", "counter", "Bytes read.", 1.5e9, promLabel{"message", "line1\nline2"})
+	m.sample("persys_exporter_up", "gauge", "Exporter is up.", 1)
+
+	want := `# HELP persys_cluster_nodes Nodes by state.
+# TYPE persys_cluster_nodes gauge
+persys_cluster_nodes{cluster="prod",state="ready"} 3
+persys_cluster_nodes{cluster="prod",state="not_ready"} 0
+# HELP persys_workload_cpu_percent CPU usage.
+# TYPE persys_workload_cpu_percent gauge
+persys_workload_cpu_percent{cluster="prod",workload="we\"b\\1"} 12.5
+# HELP persys_workload_disk_read_bytes_total Bytes read.
+# TYPE persys_workload_disk_read_bytes_total counter
+persys_workload_disk_read_bytes_total{message="line1\nline2"} 1.5e+09
+# HELP persys_exporter_up Exporter is up.
+# TYPE persys_exporter_up gauge
+persys_exporter_up 1
+`
+	if got := string(m.bytes()); got != want {
+		t.Fatalf("exposition mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPromEscape(t *testing.T) {
+	tests := map[string]string{
+		"plain":     "plain",
+		`a"b`:       `a\"b`,
+		`c:\tmp`:    `c:\\tmp`,
+		"two\nrows": `two\nrows`,
+		`\"`:        `\\\"`,
+	}
+	for in, want := range tests {
+		if got := promEscape(in); got != want {
+			t.Errorf("promEscape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
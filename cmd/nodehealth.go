@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodeHealthAll      bool
+	nodeHealthID       string
+	nodeHealthTimeout  time.Duration
+	nodeHealthParallel int
+	nodeHealthOutput   string
+)
+
+// nodeHealthRow compares the scheduler's view of a node with a live agent
+// health check.
+type nodeHealthRow struct {
+	NodeID          string  `json:"nodeId"`
+	SchedulerStatus string  `json:"schedulerStatus"`
+	HeartbeatAgeSec float64 `json:"heartbeatAgeSeconds,omitempty"`
+	Endpoint        string  `json:"endpoint"`
+	Reachable       bool    `json:"reachable"`
+	LatencyMs       float64 `json:"latencyMs,omitempty"`
+	AgentStatus     string  `json:"agentStatus,omitempty"`
+	AgentVersion    string  `json:"agentVersion,omitempty"`
+	Error           string  `json:"error,omitempty"`
+	Consistent      bool    `json:"consistent"`
+}
+
+var nodeHealthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Check agent health on nodes against the scheduler's view",
+	Run: func(cmd *cobra.Command, args []string) {
+		if nodeHealthAll == (nodeHealthID != "") {
+			cobra.CheckErr(fmt.Errorf("exactly one of --all or --id is required"))
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		var nodes []*controlv1.NodeView
+		if nodeHealthAll {
+			resp, err := c.SchedulerListNodes("")
+			cobra.CheckErr(err)
+			nodes = resp.GetNodes()
+		} else {
+			resp, err := c.GetNode(nodeHealthID)
+			cobra.CheckErr(err)
+			if resp.GetNode() == nil {
+				cobra.CheckErr(fmt.Errorf("node %s not found", nodeHealthID))
+			}
+			nodes = []*controlv1.NodeView{resp.GetNode()}
+		}
+
+		// Nodes without a registered endpoint are reported, not dialed.
+		var endpoints []string
+		probeIndex := make([]int, len(nodes))
+		for i, n := range nodes {
+			probeIndex[i] = -1
+			if n.GetGrpcEndpoint() != "" {
+				probeIndex[i] = len(endpoints)
+				endpoints = append(endpoints, n.GetGrpcEndpoint())
+			}
+		}
+		var probes []client.AgentProbe
+		if len(endpoints) > 0 {
+			probes, err = c.ProbeAgents(endpoints, nodeHealthTimeout, nodeHealthParallel)
+			cobra.CheckErr(err)
+		}
+
+		now := time.Now()
+		rows := make([]nodeHealthRow, len(nodes))
+		for i, n := range nodes {
+			row := nodeHealthRow{
+				NodeID:          n.GetNodeId(),
+				SchedulerStatus: n.GetStatus(),
+				Endpoint:        n.GetGrpcEndpoint(),
+			}
+			if hb := n.GetLastHeartbeat(); hb != nil {
+				row.HeartbeatAgeSec = now.Sub(hb.AsTime()).Round(time.Second).Seconds()
+			}
+			switch {
+			case probeIndex[i] < 0:
+				row.Error = "no endpoint"
+			case probes[probeIndex[i]].Err != nil:
+				row.Error = probes[probeIndex[i]].Err.Error()
+			default:
+				p := probes[probeIndex[i]]
+				row.Reachable = true
+				row.LatencyMs = float64(p.Latency.Microseconds()) / 1000
+				row.AgentStatus, row.AgentVersion = agentHealthSummary(p.Health)
+			}
+			agentHealthy := row.Reachable && row.AgentStatus == "healthy"
+			row.Consistent = strings.EqualFold(row.SchedulerStatus, "Ready") == agentHealthy
+			rows[i] = row
+		}
+
+		if strings.EqualFold(nodeHealthOutput, "json") {
+			data, err := json.MarshalIndent(rows, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "NODE\tSCHEDULER\tHEARTBEAT AGE\tENDPOINT\tREACHABLE\tLATENCY\tAGENT\tVERSION\tCONSISTENT")
+		for _, r := range rows {
+			age, latency, agent, consistent := "-", "-", r.AgentStatus, "yes"
+			if r.HeartbeatAgeSec > 0 {
+				age = (time.Duration(r.HeartbeatAgeSec) * time.Second).String()
+			}
+			if r.Reachable {
+				latency = fmt.Sprintf("%.1fms", r.LatencyMs)
+			} else {
+				agent = r.Error
+			}
+			if !r.Consistent {
+				consistent = "MISMATCH"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\n",
+				r.NodeID, r.SchedulerStatus, age, r.Endpoint, r.Reachable, latency, valueOrDash(agent), valueOrDash(r.AgentVersion), consistent)
+		}
+		cobra.CheckErr(tw.Flush())
+	},
+}
+
+func init() {
+	nodeCmd.AddCommand(nodeHealthCmd)
+
+	nodeHealthCmd.Flags().BoolVar(&nodeHealthAll, "all", false, "Check every node known to the scheduler")
+	nodeHealthCmd.Flags().StringVar(&nodeHealthID, "id", "", "Check a single node")
+	nodeHealthCmd.Flags().DurationVar(&nodeHealthTimeout, "timeout", 5*time.Second, "Per-node dial and health check timeout")
+	nodeHealthCmd.Flags().IntVar(&nodeHealthParallel, "parallel", 16, "Maximum concurrent health checks")
+	nodeHealthCmd.Flags().StringVarP(&nodeHealthOutput, "output", "o", "table", "Output format: table|json")
+}
+
+// agentHealthSummary reports an agent's health check as healthy or
+// unhealthy (with the agent's message, if any) and its version.
+func agentHealthSummary(resp *agentv1.HealthCheckResponse) (status, version string) {
+	status = "healthy"
+	if !resp.GetHealthy() {
+		status = "unhealthy"
+		if msg := strings.TrimSpace(resp.GetMessage()); msg != "" {
+			status += ": " + msg
+		}
+	}
+	return status, resp.GetVersion()
+}
+
+func valueOrDash(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	nodeSimID           string
+	nodeSimCPU          int64
+	nodeSimMem          int64
+	nodeSimLabels       map[string]string
+	nodeSimStoragePools []string
+	nodeSimTypes        []string
+	nodeSimDrivers      []string
+	nodeSimEndpoint     string
+	nodeSimClusterID    string
+	nodeSimInterval     time.Duration
+	nodeSimDuration     time.Duration
+	nodeSimCPUUsed      int64
+	nodeSimMemUsed      int64
+	nodeSimDiskUsed     int64
+	nodeSimJitter       float64
+	nodeSimWorkloads    []string
+	nodeSimAdopt        bool
+	nodeSimScriptFile   string
+	nodeSimAgentVersion string
+	nodeSimReregister   bool
+)
+
+// simStep is one scripted change, applied once At has elapsed since the
+// simulator registered.
+type simStep struct {
+	At       time.Duration `yaml:"at"`
+	Workload string        `yaml:"workload,omitempty"`
+	State    string        `yaml:"state,omitempty"`
+	Reason   string        `yaml:"reason,omitempty"`
+	Message  string        `yaml:"message,omitempty"`
+	// Silence stops heartbeats for the given duration, to simulate a node
+	// that drops off the network.
+	Silence time.Duration `yaml:"silence,omitempty"`
+	// CPUUsed and MemUsed replace the reported usage from this step on;
+	// 0 is a valid value, so unset is nil.
+	CPUUsed *int64 `yaml:"cpuUsed,omitempty"`
+	MemUsed *int64 `yaml:"memUsed,omitempty"`
+	// Exit stops the simulator.
+	Exit bool `yaml:"exit,omitempty"`
+
+	done bool
+}
+
+var nodeSimulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Register a synthetic node and send heartbeats for it (scheduler gRPC)",
+	Long: `Registers a synthetic node with the scheduler and keeps it alive with
+periodic heartbeats, so placement can be demoed or load-tested without real
+agents.
+
+Workloads given with --workload id=State are reported in every heartbeat;
+with --adopt, workloads the scheduler assigns to the node are reported as
+running. A --script file can change workload states, inject failure reasons,
+change usage or silence heartbeats at given offsets:
+
+  steps:
+    - at: 30s
+      workload: web-1
+      state: Failed
+      reason: NETWORK_ERROR
+      message: link down
+    - at: 1m
+      silence: 45s
+    - at: 3m
+      exit: true`,
+	Run: func(cmd *cobra.Command, args []string) {
+		pools, err := parseStoragePools(nodeSimStoragePools)
+		cobra.CheckErr(err)
+		statuses := map[string]*controlv1.WorkloadStatus{}
+		for _, raw := range nodeSimWorkloads {
+			id, state, _ := strings.Cut(raw, "=")
+			if strings.TrimSpace(id) == "" {
+				cobra.CheckErr(fmt.Errorf("invalid --workload %q (expected id=State)", raw))
+			}
+			if state == "" {
+				state = "Running"
+			}
+			statuses[id] = &controlv1.WorkloadStatus{WorkloadId: id, State: state, LastTransition: timestamppb.Now()}
+		}
+		var steps []*simStep
+		if nodeSimScriptFile != "" {
+			steps, err = loadSimScript(nodeSimScriptFile)
+			cobra.CheckErr(err)
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		register := &controlv1.RegisterNodeRequest{
+			NodeId: nodeSimID,
+			Capabilities: &controlv1.NodeCapabilities{
+				CpuTotalMillicores:      nodeSimCPU,
+				MemoryTotalMb:           nodeSimMem,
+				StoragePools:            pools,
+				SupportedWorkloadTypes:  nodeSimTypes,
+				SupportedStorageDrivers: nodeSimDrivers,
+			},
+			Labels:       nodeSimLabels,
+			AgentVersion: nodeSimAgentVersion,
+			GrpcEndpoint: nodeSimEndpoint,
+			ClusterId:    nodeSimClusterID,
+		}
+		interval, err := registerSimNode(c, register)
+		cobra.CheckErr(err)
+		if nodeSimInterval > 0 {
+			interval = nodeSimInterval
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if nodeSimDuration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, nodeSimDuration)
+			defer cancel()
+		}
+
+		started := time.Now()
+		cpuUsed, memUsed := nodeSimCPUUsed, nodeSimMemUsed
+		var silentUntil time.Time
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			elapsed := time.Since(started)
+			for _, step := range steps {
+				if step.done || elapsed < step.At {
+					continue
+				}
+				step.done = true
+				if step.Exit {
+					simLog("script: exit")
+					return
+				}
+				if step.Silence > 0 {
+					silentUntil = time.Now().Add(step.Silence)
+					simLog("script: silencing heartbeats for %s", step.Silence)
+				}
+				if step.CPUUsed != nil {
+					cpuUsed = *step.CPUUsed
+				}
+				if step.MemUsed != nil {
+					memUsed = *step.MemUsed
+				}
+				if step.Workload != "" {
+					st, err := simWorkloadStatus(step)
+					if err != nil {
+						simLog("script: %v", err)
+						continue
+					}
+					statuses[step.Workload] = st
+					simLog("script: workload %s -> %s %s", step.Workload, st.GetState(), st.GetFailureReason())
+				}
+			}
+
+			if nodeSimAdopt {
+				adoptAssignedWorkloads(c, statuses)
+			}
+
+			if time.Now().After(silentUntil) {
+				resp, err := c.Heartbeat(&controlv1.HeartbeatRequest{
+					NodeId:           nodeSimID,
+					Usage:            simUsage(cpuUsed, memUsed),
+					WorkloadStatuses: sortedStatuses(statuses),
+					Timestamp:        timestamppb.Now(),
+				})
+				switch {
+				case err != nil:
+					simLog("heartbeat failed: %v", err)
+				case !resp.GetAcknowledged() && nodeSimReregister:
+					simLog("heartbeat not acknowledged; registering again")
+					if _, err := registerSimNode(c, register); err != nil {
+						simLog("register failed: %v", err)
+					}
+				case !resp.GetAcknowledged():
+					simLog("heartbeat not acknowledged")
+				default:
+					msg := fmt.Sprintf("heartbeat ok (%d workloads)", len(statuses))
+					if resp.GetDrainNode() {
+						msg += "; scheduler requested drain"
+					}
+					simLog("%s", msg)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	nodeCmd.AddCommand(nodeSimulateCmd)
+
+	f := nodeSimulateCmd.Flags()
+	f.StringVar(&nodeSimID, "id", "", "Node ID to register")
+	f.Int64Var(&nodeSimCPU, "cpu", 4000, "Total CPU in millicores")
+	f.Int64Var(&nodeSimMem, "mem", 8192, "Total memory in MB")
+	f.StringToStringVar(&nodeSimLabels, "labels", nil, "Node labels, e.g. zone=a,disk=ssd")
+	f.StringArrayVar(&nodeSimStoragePools, "storage-pool", nil, "Storage pool name:type:sizeGB, e.g. fast:local:200 (repeatable)")
+	f.StringSliceVar(&nodeSimTypes, "types", []string{"container", "compose", "vm"}, "Supported workload types")
+	f.StringSliceVar(&nodeSimDrivers, "storage-drivers", []string{"local"}, "Supported storage drivers")
+	f.StringVar(&nodeSimEndpoint, "endpoint", "", "Agent gRPC endpoint to advertise (nothing listens on it)")
+	f.StringVar(&nodeSimClusterID, "cluster-id", "", "Cluster ID to register with")
+	f.StringVar(&nodeSimAgentVersion, "agent-version", "persysctl-simulator", "Agent version to report")
+	f.DurationVar(&nodeSimInterval, "interval", 0, "Heartbeat interval (default: as returned by RegisterNode, else 10s)")
+	f.DurationVar(&nodeSimDuration, "duration", 0, "Stop after this long (default: until interrupted)")
+	f.Int64Var(&nodeSimCPUUsed, "cpu-used", 0, "Reported CPU usage in millicores")
+	f.Int64Var(&nodeSimMemUsed, "mem-used", 0, "Reported memory usage in MB")
+	f.Int64Var(&nodeSimDiskUsed, "disk-used", 0, "Reported disk usage in GB")
+	f.Float64Var(&nodeSimJitter, "jitter", 0.1, "Random variation applied to reported usage (0.1 = ±10%)")
+	f.StringArrayVar(&nodeSimWorkloads, "workload", nil, "Workload status to report, id=State (repeatable)")
+	f.BoolVar(&nodeSimAdopt, "adopt", false, "Report workloads the scheduler assigns to this node as running (lists workloads every heartbeat)")
+	f.StringVar(&nodeSimScriptFile, "script", "", "YAML script of timed state changes and failures")
+	f.BoolVar(&nodeSimReregister, "reregister", true, "Register again when a heartbeat is not acknowledged")
+	cobra.CheckErr(nodeSimulateCmd.MarkFlagRequired("id"))
+}
+
+func registerSimNode(c *client.Client, req *controlv1.RegisterNodeRequest) (time.Duration, error) {
+	req.Timestamp = timestamppb.Now()
+	resp, err := c.RegisterNode(req)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.GetAccepted() {
+		return 0, fmt.Errorf("scheduler rejected node %s: %s", req.GetNodeId(), resp.GetReason())
+	}
+	interval := 10 * time.Second
+	if s := resp.GetHeartbeatIntervalSeconds(); s > 0 {
+		interval = time.Duration(s) * time.Second
+	}
+	simLog("registered node %s (heartbeat every %s)", req.GetNodeId(), interval)
+	return interval, nil
+}
+
+// adoptAssignedWorkloads adds workloads the scheduler placed on the
+// simulated node, reporting them in their desired state. Statuses set by
+// flags or the script are left alone.
+func adoptAssignedWorkloads(c *client.Client, statuses map[string]*controlv1.WorkloadStatus) {
+	workloads, err := c.ListWorkloads(nodeSimID, "")
+	if err != nil {
+		simLog("list assigned workloads: %v", err)
+		return
+	}
+	for _, w := range workloads {
+		if w.NodeID != nodeSimID {
+			continue
+		}
+		if _, ok := statuses[w.ID]; ok {
+			continue
+		}
+		state := "Running"
+		if strings.EqualFold(w.DesiredState, "Stopped") {
+			state = "Stopped"
+		}
+		statuses[w.ID] = &controlv1.WorkloadStatus{WorkloadId: w.ID, State: state, LastTransition: timestamppb.Now()}
+		simLog("adopted workload %s as %s", w.ID, state)
+	}
+}
+
+func simWorkloadStatus(step *simStep) (*controlv1.WorkloadStatus, error) {
+	state := step.State
+	if state == "" {
+		state = "Failed"
+	}
+	st := &controlv1.WorkloadStatus{
+		WorkloadId:     step.Workload,
+		State:          state,
+		Message:        step.Message,
+		LastTransition: timestamppb.Now(),
+	}
+	if step.Reason != "" {
+		v, ok := controlv1.FailureReason_value[strings.ToUpper(strings.TrimSpace(step.Reason))]
+		if !ok {
+			return nil, fmt.Errorf("unknown failure reason %q for workload %s", step.Reason, step.Workload)
+		}
+		st.FailureReason = controlv1.FailureReason(v)
+		st.Reason = &controlv1.ReasonDetail{
+			Code:           st.FailureReason.String(),
+			Message:        step.Message,
+			LastTransition: st.LastTransition,
+			Retryable:      st.FailureReason != controlv1.FailureReason_INVALID_SPEC,
+		}
+	}
+	return st, nil
+}
+
+func simUsage(cpuUsed, memUsed int64) *controlv1.NodeUsage {
+	jitter := func(v int64) int64 {
+		if v <= 0 || nodeSimJitter <= 0 {
+			return v
+		}
+		return int64(float64(v) * (1 + nodeSimJitter*(2*rand.Float64()-1)))
+	}
+	return &controlv1.NodeUsage{
+		CpuAllocatedMillicores: cpuUsed,
+		CpuUsedMillicores:      jitter(cpuUsed),
+		MemoryAllocatedMb:      memUsed,
+		MemoryUsedMb:           jitter(memUsed),
+		DiskAllocatedGb:        nodeSimDiskUsed,
+		DiskUsedGb:             nodeSimDiskUsed,
+	}
+}
+
+func sortedStatuses(statuses map[string]*controlv1.WorkloadStatus) []*controlv1.WorkloadStatus {
+	out := make([]*controlv1.WorkloadStatus, 0, len(statuses))
+	for _, st := range statuses {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GetWorkloadId() < out[j].GetWorkloadId() })
+	return out
+}
+
+// parseStoragePools parses name:type:sizeGB specs.
+func parseStoragePools(specs []string) ([]*controlv1.StoragePool, error) {
+	pools := make([]*controlv1.StoragePool, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --storage-pool %q (expected name:type:sizeGB)", spec)
+		}
+		size, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid --storage-pool %q: bad size", spec)
+		}
+		pools = append(pools, &controlv1.StoragePool{Name: parts[0], Type: parts[1], TotalGb: size})
+	}
+	return pools, nil
+}
+
+func loadSimScript(path string) ([]*simStep, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+	var script struct {
+		Steps []*simStep `yaml:"steps"`
+	}
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("decode script %s: %w", path, err)
+	}
+	sort.SliceStable(script.Steps, func(i, j int) bool { return script.Steps[i].At < script.Steps[j].At })
+	return script.Steps, nil
+}
+
+func simLog(format string, args ...any) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s [%s] %s\n", time.Now().UTC().Format(time.RFC3339), nodeSimID, fmt.Sprintf(format, args...))
+}
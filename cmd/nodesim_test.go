@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+)
+
+func TestParseStoragePools(t *testing.T) {
+	pools, err := parseStoragePools([]string{"fast:ssd:100", "bulk:hdd:2000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 2 {
+		t.Fatalf("got %d pools, want 2", len(pools))
+	}
+	if p := pools[0]; p.GetName() != "fast" || p.GetType() != "ssd" || p.GetTotalGb() != 100 {
+		t.Errorf("pools[0] = %v", p)
+	}
+	if p := pools[1]; p.GetName() != "bulk" || p.GetType() != "hdd" || p.GetTotalGb() != 2000 {
+		t.Errorf("pools[1] = %v", p)
+	}
+
+	for _, spec := range []string{"fast", "fast:ssd", "fast:ssd:100:x", ":ssd:100", "fast::100", "fast:ssd:big", "fast:ssd:0", "fast:ssd:-5"} {
+		if _, err := parseStoragePools([]string{spec}); err == nil {
+			t.Errorf("parseStoragePools(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestSimWorkloadStatus(t *testing.T) {
+	st, err := simWorkloadStatus(&simStep{Workload: "web-1", Reason: " network_error ", Message: "link down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetState() != "Failed" || st.GetFailureReason() != controlv1.FailureReason_NETWORK_ERROR {
+		t.Errorf("state %q reason %v, want Failed NETWORK_ERROR", st.GetState(), st.GetFailureReason())
+	}
+	if r := st.GetReason(); r.GetCode() != "NETWORK_ERROR" || !r.GetRetryable() || r.GetMessage() != "link down" {
+		t.Errorf("reason detail = %v", r)
+	}
+
+	st, err = simWorkloadStatus(&simStep{Workload: "web-1", Reason: "INVALID_SPEC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetReason().GetRetryable() {
+		t.Errorf("INVALID_SPEC reported as retryable")
+	}
+
+	st, err = simWorkloadStatus(&simStep{Workload: "web-1", State: "Running"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetState() != "Running" || st.GetReason() != nil || st.GetFailureReason() != controlv1.FailureReason_FAILURE_REASON_UNSPECIFIED {
+		t.Errorf("status without reason = %v", st)
+	}
+
+	_, err = simWorkloadStatus(&simStep{Workload: "web-1", Reason: "DISK_ON_FIRE"})
+	if err == nil || !strings.Contains(err.Error(), `unknown failure reason "DISK_ON_FIRE"`) {
+		t.Errorf("unknown reason: err = %v", err)
+	}
+}
+
+func TestLoadSimScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.yaml")
+	script := `steps:
+  - at: 1m
+    silence: 45s
+  - at: 30s
+    workload: web-1
+    state: Failed
+    reason: NETWORK_ERROR
+  - at: 1m30s
+    cpuUsed: 0
+  - at: 2m
+    memUsed: 512
+  - at: 3m
+    exit: true
+`
+	if err := os.WriteFile(path, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	steps, err := loadSimScript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 5 {
+		t.Fatalf("got %d steps, want 5", len(steps))
+	}
+	wantAt := []time.Duration{30 * time.Second, time.Minute, 90 * time.Second, 2 * time.Minute, 3 * time.Minute}
+	for i, s := range steps {
+		if s.At != wantAt[i] {
+			t.Errorf("steps[%d].At = %v, want %v", i, s.At, wantAt[i])
+		}
+	}
+	if steps[0].Workload != "web-1" || steps[0].Reason != "NETWORK_ERROR" {
+		t.Errorf("steps[0] = %+v", steps[0])
+	}
+	if steps[1].Silence != 45*time.Second {
+		t.Errorf("steps[1].Silence = %v, want 45s", steps[1].Silence)
+	}
+	// An explicit 0 is set; an omitted value stays nil.
+	if steps[2].CPUUsed == nil || *steps[2].CPUUsed != 0 || steps[2].MemUsed != nil {
+		t.Errorf("steps[2] usage = %v/%v, want 0/unset", steps[2].CPUUsed, steps[2].MemUsed)
+	}
+	if steps[3].MemUsed == nil || *steps[3].MemUsed != 512 || steps[3].CPUUsed != nil {
+		t.Errorf("steps[3] usage = %v/%v, want unset/512", steps[3].CPUUsed, steps[3].MemUsed)
+	}
+	if !steps[4].Exit {
+		t.Errorf("steps[4].Exit = false")
+	}
+
+	if err := os.WriteFile(path, []byte("steps:\n  - at: soon\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSimScript(path); err == nil {
+		t.Errorf("loadSimScript accepted an invalid duration")
+	}
+	if _, err := loadSimScript(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("loadSimScript accepted a missing file")
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodeTopStatus     string
+	nodeTopStaleAfter time.Duration
+	nodeTopOutput     string
+	nodeDescribeID    string
+)
+
+// nodeReport is a node with its allocation and hosted workloads.
+type nodeReport struct {
+	models.Node
+	CPUAllocatedPercent    float64           `json:"cpuAllocatedPercent"`
+	MemoryAllocatedPercent float64           `json:"memoryAllocatedPercent"`
+	HeartbeatAgeSeconds    float64           `json:"heartbeatAgeSeconds,omitempty"`
+	StaleHeartbeat         bool              `json:"staleHeartbeat"`
+	WorkloadCount          int               `json:"workloadCount"`
+	Workloads              []nodeWorkloadRow `json:"workloads,omitempty"`
+}
+
+type nodeWorkloadRow struct {
+	ID     string                `json:"id"`
+	Type   string                `json:"type"`
+	Status string                `json:"status"`
+	Usage  *models.WorkloadUsage `json:"usage,omitempty"`
+}
+
+var nodeTopCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show node capacity, allocation and heartbeat freshness",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		nodes, err := c.ListNodes(nodeTopStatus)
+		cobra.CheckErr(err)
+		workloads, err := c.ListWorkloads("", "")
+		cobra.CheckErr(err)
+		byNode := map[string][]models.Workload{}
+		for _, w := range workloads {
+			if workloadDeleted(w) {
+				continue
+			}
+			byNode[w.NodeID] = append(byNode[w.NodeID], w)
+		}
+
+		reports := make([]nodeReport, 0, len(nodes))
+		for _, n := range nodes {
+			reports = append(reports, buildNodeReport(n, byNode[n.NodeID], false))
+		}
+		sort.Slice(reports, func(i, j int) bool { return reports[i].NodeID < reports[j].NodeID })
+
+		if strings.EqualFold(nodeTopOutput, "json") {
+			data, err := json.MarshalIndent(reports, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "NODE\tSTATUS\tCPU(m) USED/TOTAL\tCPU%\tMEM(Mi) USED/TOTAL\tMEM%\tWORKLOADS\tHEARTBEAT")
+		for _, r := range reports {
+			hb := "-"
+			if !r.LastHeartbeat.IsZero() {
+				hb = (time.Duration(r.HeartbeatAgeSeconds) * time.Second).String() + " ago"
+				if r.StaleHeartbeat {
+					hb += " (STALE)"
+				}
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%.0f%%\t%d/%d\t%.0f%%\t%d\t%s\n",
+				r.NodeID, r.Status,
+				r.Resources.CPU-r.Available.CPU, r.Resources.CPU, r.CPUAllocatedPercent,
+				r.Resources.Memory-r.Available.Memory, r.Resources.Memory, r.MemoryAllocatedPercent,
+				r.WorkloadCount, hb)
+		}
+		cobra.CheckErr(tw.Flush())
+	},
+}
+
+var nodeDescribeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe a node with allocation and the usage of its workloads",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		node, err := c.NodeInfo(nodeDescribeID)
+		cobra.CheckErr(err)
+		workloads, err := c.ListWorkloads(nodeDescribeID, "")
+		cobra.CheckErr(err)
+
+		data, err := json.MarshalIndent(buildNodeReport(*node, workloads, true), "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	nodeCmd.AddCommand(nodeTopCmd)
+	nodeCmd.AddCommand(nodeDescribeCmd)
+
+	nodeTopCmd.Flags().StringVar(&nodeTopStatus, "status", "", "Filter by status: Ready|NotReady|Draining")
+	nodeTopCmd.Flags().DurationVar(&nodeTopStaleAfter, "stale-after", time.Minute, "Flag nodes whose last heartbeat is older than this")
+	nodeTopCmd.Flags().StringVarP(&nodeTopOutput, "output", "o", "table", "Output format: table|json")
+
+	nodeDescribeCmd.Flags().StringVar(&nodeDescribeID, "id", "", "Node ID")
+	nodeDescribeCmd.Flags().DurationVar(&nodeTopStaleAfter, "stale-after", time.Minute, "Flag the node if its last heartbeat is older than this")
+	cobra.CheckErr(nodeDescribeCmd.MarkFlagRequired("id"))
+}
+
+// buildNodeReport summarises n. Deleted workloads are not counted as hosted.
+func buildNodeReport(n models.Node, workloads []models.Workload, withWorkloads bool) nodeReport {
+	live := make([]models.Workload, 0, len(workloads))
+	for _, w := range workloads {
+		if !workloadDeleted(w) {
+			live = append(live, w)
+		}
+	}
+	workloads = live
+
+	r := nodeReport{
+		Node:                   n,
+		CPUAllocatedPercent:    allocatedPercent(n.Resources.CPU, n.Available.CPU),
+		MemoryAllocatedPercent: allocatedPercent(n.Resources.Memory, n.Available.Memory),
+		WorkloadCount:          len(workloads),
+	}
+	if !n.LastHeartbeat.IsZero() {
+		age := time.Since(n.LastHeartbeat)
+		r.HeartbeatAgeSeconds = age.Round(time.Second).Seconds()
+		r.StaleHeartbeat = age > nodeTopStaleAfter
+	} else {
+		r.StaleHeartbeat = true
+	}
+	if withWorkloads {
+		for _, w := range workloads {
+			r.Workloads = append(r.Workloads, nodeWorkloadRow{ID: w.ID, Type: w.Type, Status: w.Status, Usage: w.Usage})
+		}
+		sort.Slice(r.Workloads, func(i, j int) bool { return r.Workloads[i].ID < r.Workloads[j].ID })
+	}
+	return r
+}
+
+func allocatedPercent(total, available int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	used := total - available
+	if used < 0 {
+		used = 0
+	}
+	return float64(used) * 100 / float64(total)
+}
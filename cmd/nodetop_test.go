@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+func TestAllocatedPercent(t *testing.T) {
+	tests := []struct {
+		total, available int
+		want             float64
+	}{
+		{4000, 1000, 75},
+		{4000, 4000, 0},
+		{4000, 0, 100},
+		{0, 0, 0},
+		{-1, 0, 0},
+		// Over-reported availability never yields a negative allocation.
+		{1000, 1500, 0},
+	}
+	for _, tt := range tests {
+		if got := allocatedPercent(tt.total, tt.available); got != tt.want {
+			t.Errorf("allocatedPercent(%d, %d) = %v, want %v", tt.total, tt.available, got, tt.want)
+		}
+	}
+}
+
+func TestBuildNodeReport(t *testing.T) {
+	defer func(d time.Duration) { nodeTopStaleAfter = d }(nodeTopStaleAfter)
+	nodeTopStaleAfter = time.Minute
+
+	workloads := []models.Workload{
+		{ID: "web", Type: "container", Status: "Running"},
+		{ID: "api", Type: "container", Status: "Pending"},
+		{ID: "old", Type: "container", Status: "Deleted"},
+		{ID: "gone", Type: "container", Status: "Running", DesiredState: "deleted"},
+	}
+
+	fresh := models.Node{
+		NodeID:        "node-a",
+		LastHeartbeat: time.Now().Add(-10 * time.Second),
+		Resources:     models.Resources{CPU: 4000, Memory: 8192},
+		Available:     models.Resources{CPU: 1000, Memory: 2048},
+	}
+	r := buildNodeReport(fresh, workloads, true)
+	if r.StaleHeartbeat {
+		t.Errorf("fresh node reported stale (age %vs)", r.HeartbeatAgeSeconds)
+	}
+	if r.CPUAllocatedPercent != 75 || r.MemoryAllocatedPercent != 75 {
+		t.Errorf("allocation = %v/%v, want 75/75", r.CPUAllocatedPercent, r.MemoryAllocatedPercent)
+	}
+	if r.WorkloadCount != 2 || len(r.Workloads) != 2 || r.Workloads[0].ID != "api" || r.Workloads[1].ID != "web" {
+		t.Errorf("workloads = %d %+v, want api and web only", r.WorkloadCount, r.Workloads)
+	}
+	if r := buildNodeReport(fresh, workloads, false); r.WorkloadCount != 2 || r.Workloads != nil {
+		t.Errorf("without workloads: count %d rows %+v", r.WorkloadCount, r.Workloads)
+	}
+
+	stale := models.Node{NodeID: "node-b", LastHeartbeat: time.Now().Add(-5 * time.Minute)}
+	r = buildNodeReport(stale, nil, false)
+	if !r.StaleHeartbeat || r.HeartbeatAgeSeconds < 299 {
+		t.Errorf("stale node: stale=%v age=%v", r.StaleHeartbeat, r.HeartbeatAgeSeconds)
+	}
+	// A node without capacity reports zero allocation rather than dividing by zero.
+	if r.CPUAllocatedPercent != 0 || r.MemoryAllocatedPercent != 0 {
+		t.Errorf("zero-total allocation = %v/%v, want 0/0", r.CPUAllocatedPercent, r.MemoryAllocatedPercent)
+	}
+
+	never := buildNodeReport(models.Node{NodeID: "node-c"}, nil, false)
+	if !never.StaleHeartbeat || never.HeartbeatAgeSeconds != 0 {
+		t.Errorf("node without heartbeat: stale=%v age=%v", never.StaleHeartbeat, never.HeartbeatAgeSeconds)
+	}
+}
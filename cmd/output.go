@@ -9,6 +9,7 @@ import (
 	"github.com/persys-dev/persysctl/internal/config"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
 )
 
 func printProto(msg proto.Message) {
@@ -21,7 +22,43 @@ func printProto(msg proto.Message) {
 		fmt.Println("{}")
 		return
 	}
-	fmt.Println(string(b))
+	fmt.Println(client.RedactURLCredentials(string(b)))
+}
+
+// printProtoAs prints msg as "json" (the printProto layout) or "yaml".
+func printProtoAs(msg proto.Message, format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		printProto(msg)
+		return nil
+	case "yaml":
+		b, err := protojson.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		// Decoding JSON into a yaml.Node keeps field order; clearing the
+		// flow style makes the encoder emit block YAML.
+		var node yaml.Node
+		if err := yaml.Unmarshal(b, &node); err != nil {
+			return err
+		}
+		clearYAMLStyle(&node)
+		out, err := yaml.Marshal(&node)
+		if err != nil {
+			return err
+		}
+		fmt.Print(client.RedactURLCredentials(string(out)))
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q (expected json or yaml)", format)
+	}
+}
+
+func clearYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		clearYAMLStyle(child)
+	}
 }
 
 func newClientWithTrace() (*client.Client, config.Config, error) {
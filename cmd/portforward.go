@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/persys-dev/persysctl/internal/agentstream"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var workloadPortForwardAddress string
+
+var workloadPortForwardCmd = &cobra.Command{
+	Use:   "port-forward <id> [local:]remote [...]",
+	Short: "Forward local TCP ports to ports inside a workload (experimental)",
+	Long: `Forward local TCP ports to ports inside a workload through its compute-agent.
+
+Port specs are local:remote, remote (same local port) or :remote (random local port).
+
+Experimental: connections are tunnelled over the PortForward stream of
+persysctl.experimental.v1.WorkloadStreams, which is not part of the
+persys-cloud agent API yet.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		type forward struct {
+			local  int
+			remote uint32
+		}
+		forwards := make([]forward, 0, len(args)-1)
+		for _, spec := range args[1:] {
+			local, remote, err := parsePortForwardSpec(spec)
+			cobra.CheckErr(err)
+			forwards = append(forwards, forward{local: local, remote: remote})
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		pf, err := c.NewPortForwarder(args[0])
+		cobra.CheckErr(err)
+		defer pf.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		listeners := make([]net.Listener, 0, len(forwards))
+		for _, f := range forwards {
+			addr := net.JoinHostPort(workloadPortForwardAddress, strconv.Itoa(f.local))
+			l, err := net.Listen("tcp", addr)
+			if err != nil {
+				for _, l := range listeners {
+					_ = l.Close()
+				}
+				cobra.CheckErr(fmt.Errorf("listen on %s: %w", addr, err))
+			}
+			listeners = append(listeners, l)
+			_, _ = fmt.Fprintf(os.Stderr, "Forwarding from %s -> %d\n", l.Addr(), f.remote)
+			go acceptPortForward(ctx, l, pf, f.remote)
+		}
+
+		<-ctx.Done()
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadPortForwardCmd)
+	workloadPortForwardCmd.Flags().StringVar(&workloadPortForwardAddress, "address", "127.0.0.1", "Local address to listen on")
+}
+
+func acceptPortForward(ctx context.Context, l net.Listener, pf *client.PortForwarder, remote uint32) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			// Proxy can return with the stream still open; cancelling the
+			// tunnel's own context releases it.
+			tctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			tunnel, err := pf.Open(tctx, remote)
+			if err != nil {
+				_ = conn.Close()
+				if errors.Is(err, agentstream.ErrUnsupported) {
+					_, _ = fmt.Fprintf(os.Stderr, "port-forward is not supported by the compute-agent: %v\n", err)
+					return
+				}
+				_, _ = fmt.Fprintf(os.Stderr, "port-forward to %d: %v\n", remote, err)
+				return
+			}
+			if err := tunnel.Proxy(conn); err != nil && ctx.Err() == nil {
+				_, _ = fmt.Fprintf(os.Stderr, "port-forward to %d: %v\n", remote, err)
+			}
+		}()
+	}
+}
+
+// parsePortForwardSpec parses local:remote, remote or :remote.
+func parsePortForwardSpec(spec string) (int, uint32, error) {
+	localStr, remoteStr := spec, spec
+	if i := strings.Index(spec, ":"); i >= 0 {
+		localStr, remoteStr = spec[:i], spec[i+1:]
+	}
+	remote, err := strconv.ParseUint(strings.TrimSpace(remoteStr), 10, 16)
+	if err != nil || remote == 0 {
+		return 0, 0, fmt.Errorf("invalid port spec %q: bad remote port", spec)
+	}
+	local := 0
+	if strings.TrimSpace(localStr) != "" {
+		l, err := strconv.ParseUint(strings.TrimSpace(localStr), 10, 16)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port spec %q: bad local port", spec)
+		}
+		local = int(l)
+	}
+	return local, uint32(remote), nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import "testing"
+
+func TestParsePortForwardSpec(t *testing.T) {
+	tests := []struct {
+		spec   string
+		local  int
+		remote uint32
+	}{
+		{"8080:80", 8080, 80},
+		{"5432", 5432, 5432},
+		{":5432", 0, 5432},
+		{" 9000 : 90 ", 9000, 90},
+		{"0:443", 0, 443},
+	}
+	for _, tt := range tests {
+		local, remote, err := parsePortForwardSpec(tt.spec)
+		if err != nil || local != tt.local || remote != tt.remote {
+			t.Errorf("parsePortForwardSpec(%q) = %d, %d, %v; want %d, %d", tt.spec, local, remote, err, tt.local, tt.remote)
+		}
+	}
+
+	for _, spec := range []string{"", ":", "8080:", "8080:0", "x:80", "8080:http", "70000:80", "80:70000", "1:2:3"} {
+		if _, _, err := parsePortForwardSpec(spec); err == nil {
+			t.Errorf("parsePortForwardSpec(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package cmd
+
+import (
+	"context"
+	"time"
+)
+
+// watchTerminalResize polls for size changes, since Windows consoles have
+// no SIGWINCH. fn is expected to ignore unchanged sizes.
+func watchTerminalResize(ctx context.Context, fn func()) {
+	go func() {
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
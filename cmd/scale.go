@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// replicaOfKey is the spec metadata key set on fanned-out replicas.
+const replicaOfKey = "persys.io/replica-of"
+
+// replicaIDPattern matches fanned-out replica IDs: <base>-r<index>.
+var replicaIDPattern = regexp.MustCompile(`^(.+)-r([0-9]+)$`)
+
+var (
+	workloadScaleReplicas int32
+	workloadScaleDelta    int32
+	workloadScaleMode     string
+	workloadScaleReason   string
+
+	workloadListGroupReplicas bool
+)
+
+var workloadScaleCmd = &cobra.Command{
+	Use:   "scale <id>",
+	Short: "Change the number of replicas of a workload",
+	Long: `Asks the scheduler to scale a workload with an AUTOMATION_ACTION_SCALE_REPLICAS
+suggestion. When the scheduler has no native replica support (or with
+--mode fanout), the CLI instead runs N copies of the workload's last applied
+spec as <id>-r1, <id>-r2, ... next to <id> itself.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		baseID := args[0]
+		replicasSet := cmd.Flags().Changed("replicas")
+		deltaSet := cmd.Flags().Changed("delta")
+		if replicasSet == deltaSet {
+			cobra.CheckErr(fmt.Errorf("exactly one of --replicas or --delta is required"))
+		}
+		if replicasSet && workloadScaleReplicas < 1 {
+			cobra.CheckErr(fmt.Errorf("--replicas must be at least 1 (use workload delete to remove a workload)"))
+		}
+		mode := strings.ToLower(strings.TrimSpace(workloadScaleMode))
+		if mode != "auto" && mode != "native" && mode != "fanout" {
+			cobra.CheckErr(fmt.Errorf("invalid --mode %q (expected auto|native|fanout)", workloadScaleMode))
+		}
+
+		cobra.CheckErr(runWorkloadScale(baseID, replicasSet, mode))
+	},
+}
+
+// runWorkloadScale scales baseID natively or by fan-out depending on mode.
+// Errors are returned rather than exiting so the client is always closed.
+func runWorkloadScale(baseID string, absolute bool, mode string) error {
+	c, cfg, err := newClientWithTrace()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	native := cfg.Transport == "grpc" && cfg.GRPCTarget == "scheduler"
+	switch {
+	case mode == "fanout":
+	case !native && mode == "auto":
+		_, _ = fmt.Fprintln(os.Stderr, "native scaling needs the scheduler gRPC target; using fan-out scaling")
+	default:
+		resp, err := submitNativeScale(c, baseID, absolute)
+		if err != nil && !(mode == "auto" && isUnimplemented(err)) {
+			return err
+		}
+		if err == nil && (resp.GetAccepted() || mode == "native" || !scaleUnsupported(resp.GetDecision(), resp.GetReason())) {
+			out := map[string]any{
+				"workloadId": baseID,
+				"mode":       "native",
+				"accepted":   resp.GetAccepted(),
+				"decision":   resp.GetDecision(),
+			}
+			if resp.GetReason() != "" {
+				out["reason"] = resp.GetReason()
+			}
+			if resp.GetAppliedAction() != "" {
+				out["appliedAction"] = resp.GetAppliedAction()
+			}
+			printScaleResult(out)
+			if !resp.GetAccepted() {
+				return fmt.Errorf("scheduler did not accept scaling %s", baseID)
+			}
+			return nil
+		}
+		reason := "scheduler has no native replica support"
+		if err != nil {
+			reason = err.Error()
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "falling back to fan-out scaling: %s\n", reason)
+	}
+
+	return fanOutScale(c, baseID, absolute)
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadScaleCmd)
+
+	workloadScaleCmd.Flags().Int32Var(&workloadScaleReplicas, "replicas", 0, "Desired number of replicas")
+	workloadScaleCmd.Flags().Int32Var(&workloadScaleDelta, "delta", 0, "Change in replicas, e.g. +2 or -1")
+	workloadScaleCmd.Flags().StringVar(&workloadScaleMode, "mode", "auto", "Scaling mode: auto (native, falling back to fan-out)|native|fanout")
+	workloadScaleCmd.Flags().StringVar(&workloadScaleReason, "reason", "", "Reason recorded with the scale suggestion")
+}
+
+func submitNativeScale(c *client.Client, baseID string, absolute bool) (*controlv1.SubmitAutomationSuggestionResponse, error) {
+	reason := workloadScaleReason
+	if reason == "" {
+		reason = "workload scale from persysctl"
+	}
+	suggestion := &controlv1.AutomationSuggestion{
+		PolicyId:       "manual-scale",
+		PolicyName:     "persysctl",
+		TargetWorkload: baseID,
+		ActionType:     controlv1.AutomationActionType_AUTOMATION_ACTION_SCALE_REPLICAS,
+		Reason:         reason,
+	}
+	if absolute {
+		suggestion.DesiredReplicas = workloadScaleReplicas
+	} else {
+		suggestion.ReplicaDelta = workloadScaleDelta
+	}
+	return c.SubmitAutomationSuggestion(suggestion)
+}
+
+// scaleUnsupported reports whether a rejected scale suggestion means the
+// scheduler cannot scale at all, as opposed to refusing this request.
+func scaleUnsupported(texts ...string) bool {
+	for _, t := range texts {
+		t = strings.ToLower(t)
+		if strings.Contains(t, "unsupported") || strings.Contains(t, "not supported") || strings.Contains(t, "not implemented") {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnimplemented reports whether err is a gRPC Unimplemented status.
+func isUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
+// fanOutScale creates or deletes <base>-rN workloads so that the group has
+// the requested size. The base workload always counts as the first replica.
+func fanOutScale(c *client.Client, baseID string, absolute bool) error {
+	workloads, err := c.ListWorkloads("", "")
+	if err != nil {
+		return err
+	}
+	groups := replicaGroups(c, workloads)
+	members := groups[baseID]
+	if len(members) == 0 {
+		found := false
+		for _, w := range workloads {
+			if w.ID == baseID && !workloadDeleted(w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("workload %s not found", baseID)
+		}
+		members = []string{baseID}
+	}
+	current := int32(len(members))
+	desired := workloadScaleReplicas
+	if !absolute {
+		desired = current + workloadScaleDelta
+	}
+	if desired < 1 {
+		return fmt.Errorf("cannot scale %s below 1 replica (use workload delete to remove it)", baseID)
+	}
+
+	out := map[string]any{
+		"workloadId": baseID,
+		"mode":       "fanout",
+		"current":    current,
+		"desired":    desired,
+	}
+	var created, deleted []string
+	if desired > current {
+		entries, err := c.History().List(baseID)
+		if err != nil || len(entries) == 0 {
+			return fmt.Errorf("no locally recorded spec for %s to copy; apply it with persysctl first", baseID)
+		}
+		latest := entries[len(entries)-1]
+		// Never reuse the ID of a live workload, replica or not: applying
+		// would overwrite it.
+		used := map[string]bool{}
+		for _, w := range workloads {
+			if !workloadDeleted(w) {
+				used[w.ID] = true
+			}
+		}
+		for next := 1; int32(len(members)+len(created)) < desired; next++ {
+			id := fmt.Sprintf("%s-r%d", baseID, next)
+			if used[id] {
+				continue
+			}
+			spec := &controlv1.WorkloadSpec{}
+			if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(latest.Spec, spec); err != nil {
+				return fmt.Errorf("decode recorded spec for %s: %w", baseID, err)
+			}
+			if spec.Metadata == nil {
+				spec.Metadata = map[string]string{}
+			}
+			spec.Metadata[replicaOfKey] = baseID
+			desiredState := latest.DesiredState
+			if desiredState == "" {
+				desiredState = "Running"
+			}
+			req := &controlv1.ApplyWorkloadRequest{
+				WorkloadId:   id,
+				RevisionId:   revisionFor("", spec),
+				DesiredState: desiredState,
+				Spec:         spec,
+			}
+			resp, err := c.ApplySchedulerWorkload(req)
+			if err != nil {
+				return fmt.Errorf("create replica %s: %w", id, err)
+			}
+			if !resp.GetSuccess() {
+				return fmt.Errorf("create replica %s: %s", id, resp.GetErrorMessage())
+			}
+			// Listings do not carry spec metadata; the recorded spec is
+			// what later identifies id as a replica of baseID.
+			c.RecordHistory(req)
+			created = append(created, id)
+		}
+	}
+	if desired < current {
+		// members are sorted base first, then by replica index; remove
+		// the highest indexes first.
+		for i := len(members) - 1; int32(i) >= desired; i-- {
+			resp, err := c.DeleteWorkload(members[i])
+			if err != nil {
+				return fmt.Errorf("delete replica %s: %w", members[i], err)
+			}
+			if !resp.GetSuccess() {
+				return fmt.Errorf("delete replica %s: %s", members[i], resp.GetErrorMessage())
+			}
+			deleted = append(deleted, members[i])
+		}
+	}
+	if len(created) > 0 {
+		out["created"] = created
+	}
+	if len(deleted) > 0 {
+		out["deleted"] = deleted
+	}
+	printScaleResult(out)
+	return nil
+}
+
+func printScaleResult(out map[string]any) {
+	data, err := json.MarshalIndent(out, "", "  ")
+	cobra.CheckErr(err)
+	fmt.Println(string(data))
+}
+
+// replicaBase returns the base ID and index of a fanned-out replica ID.
+func replicaBase(id string) (string, int, bool) {
+	m := replicaIDPattern.FindStringSubmatch(id)
+	if m == nil {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+	return m[1], n, true
+}
+
+// workloadDeleted reports whether the scheduler lists w as deleted.
+func workloadDeleted(w models.Workload) bool {
+	return strings.EqualFold(w.Status, "Deleted") || strings.EqualFold(w.DesiredState, "Deleted")
+}
+
+// replicaGroups maps each base workload that has fanned-out replicas to its
+// members: the base first, then replicas by index. Only workloads whose
+// replicaOfKey metadata names a listed base count as replicas; deleted
+// workloads are ignored. Metadata is resolved with workloadLabels, since
+// scheduler listings leave it empty.
+func replicaGroups(c *client.Client, workloads []models.Workload) map[string][]string {
+	present := make(map[string]bool, len(workloads))
+	for _, w := range workloads {
+		if !workloadDeleted(w) {
+			present[w.ID] = true
+		}
+	}
+	index := map[string]int{}
+	groups := map[string][]string{}
+	for _, w := range workloads {
+		if workloadDeleted(w) {
+			continue
+		}
+		base := workloadLabels(c, w)[replicaOfKey]
+		if base == "" || base == w.ID || !present[base] {
+			continue
+		}
+		if _, seen := groups[base]; !seen {
+			groups[base] = []string{base}
+		}
+		groups[base] = append(groups[base], w.ID)
+		// Replicas not named <base>-rN sort after the numbered ones.
+		index[w.ID] = math.MaxInt
+		if b, n, ok := replicaBase(w.ID); ok && b == base {
+			index[w.ID] = n
+		}
+	}
+	for base, members := range groups {
+		replicas := members[1:]
+		sort.Slice(replicas, func(i, j int) bool {
+			a, b := replicas[i], replicas[j]
+			if index[a] != index[b] {
+				return index[a] < index[b]
+			}
+			return a < b
+		})
+		groups[base] = members
+	}
+	return groups
+}
+
+// formatReplicaGroups renders a workload listing as replica groups. Workloads
+// without replicas form groups of one.
+func formatReplicaGroups(c *client.Client, workloads []models.Workload) []map[string]any {
+	byID := make(map[string]models.Workload, len(workloads))
+	for _, w := range workloads {
+		byID[w.ID] = w
+	}
+	groups := replicaGroups(c, workloads)
+	grouped := map[string]bool{}
+	for _, members := range groups {
+		for _, id := range members[1:] {
+			grouped[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(workloads))
+	for _, w := range workloads {
+		if !grouped[w.ID] {
+			ids = append(ids, w.ID)
+		}
+	}
+	sort.Strings(ids)
+	out := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		members := groups[id]
+		if len(members) == 0 {
+			members = []string{id}
+		}
+		running := 0
+		statuses := make([]map[string]any, 0, len(members))
+		for _, m := range members {
+			w := byID[m]
+			if strings.EqualFold(w.Status, "Running") {
+				running++
+			}
+			member := map[string]any{"id": m, "status": w.Status}
+			if w.NodeID != "" {
+				member["nodeId"] = w.NodeID
+			}
+			statuses = append(statuses, member)
+		}
+		out = append(out, map[string]any{
+			"group":    id,
+			"type":     byID[id].Type,
+			"replicas": len(members),
+			"running":  running,
+			"members":  statuses,
+		})
+	}
+	return out
+}
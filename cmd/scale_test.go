@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/config"
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+// newHistoryClient returns a client that never dials, with its revision
+// history in a temporary directory.
+func newHistoryClient(t *testing.T) *client.Client {
+	t.Helper()
+	c, err := client.NewClient(config.Config{Transport: "http", APIEndpoint: "http://127.0.0.1:1", HistoryDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// recordReplica records id as fanOutScale does after creating a replica.
+func recordReplica(c *client.Client, id, base string) {
+	c.RecordHistory(&controlv1.ApplyWorkloadRequest{
+		WorkloadId:   id,
+		DesiredState: "Running",
+		Spec: &controlv1.WorkloadSpec{
+			Type:     "container",
+			Metadata: map[string]string{replicaOfKey: base},
+		},
+	})
+}
+
+// listed returns workloads as the scheduler lists them: without metadata.
+func listed(ids ...string) []models.Workload {
+	out := make([]models.Workload, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, models.Workload{ID: id, Type: "container", Status: "Running"})
+	}
+	return out
+}
+
+func TestReplicaGroups(t *testing.T) {
+	c := newHistoryClient(t)
+	for _, id := range []string{"web-r10", "web-r2", "web-canary", "web-r1", "web-r4"} {
+		recordReplica(c, id, "web")
+	}
+	recordReplica(c, "api-r1", "api")
+	recordReplica(c, "db-r1", "db")
+	recordReplica(c, "self", "self")
+
+	workloads := listed("web-r10", "web", "web-r2", "web-canary", "web-r1",
+		// Named like a replica but without a recorded marker: not part of the group.
+		"web-r3", "api-r1", "db-r1", "self")
+	workloads = append(workloads,
+		// Deleted replicas are ignored.
+		models.Workload{ID: "web-r4", Status: "Deleted"},
+		// The marker must name a listed, live base.
+		models.Workload{ID: "api", Status: "Deleted"},
+	)
+	want := map[string][]string{
+		"web": {"web", "web-r1", "web-r2", "web-r10", "web-canary"},
+	}
+	if got := replicaGroups(c, workloads); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replicaGroups = %v, want %v", got, want)
+	}
+}
+
+func TestReplicaGroupsPrefersListedMetadata(t *testing.T) {
+	c := newHistoryClient(t)
+	workloads := listed("web")
+	workloads = append(workloads, models.Workload{ID: "web-r1", Status: "Running", Metadata: map[string]string{replicaOfKey: "web"}})
+	want := map[string][]string{"web": {"web", "web-r1"}}
+	if got := replicaGroups(c, workloads); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replicaGroups = %v, want %v", got, want)
+	}
+}
+
+func TestReplicaGroupsWithoutReplicas(t *testing.T) {
+	c := newHistoryClient(t)
+	if got := replicaGroups(c, listed("web", "web-r1")); len(got) != 0 {
+		t.Fatalf("replicaGroups = %v, want no groups", got)
+	}
+}
+
+func TestFormatReplicaGroups(t *testing.T) {
+	c := newHistoryClient(t)
+	recordReplica(c, "web-r1", "web")
+	workloads := listed("web", "web-r1", "api")
+	workloads[1].Status = "Pending"
+
+	got := formatReplicaGroups(c, workloads)
+	if len(got) != 2 || got[0]["group"] != "api" || got[1]["group"] != "web" {
+		t.Fatalf("formatReplicaGroups = %v, want groups api and web", got)
+	}
+	if got[1]["replicas"] != 2 || got[1]["running"] != 1 {
+		t.Fatalf("web group = %v, want 2 replicas with 1 running", got[1])
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
 	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
@@ -52,17 +51,33 @@ var schedulerApplyCmd = &cobra.Command{
 
 		req := &controlv1.ApplyWorkloadRequest{
 			WorkloadId:   schedulerApplyID,
-			RevisionId:   schedulerApplyRevision,
+			RevisionId:   revisionFor(schedulerApplyRevision, spec),
 			DesiredState: normalizeDesiredState(schedulerApplyDesired),
 			Spec:         spec,
 		}
 
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		if mode == "client" {
+			cobra.CheckErr(printProtoAs(req, dryRunOutput))
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
 
+		if mode == "server" {
+			resp, err := c.PreviewSchedulerWorkload(req)
+			cobra.CheckErr(err)
+			cobra.CheckErr(printProtoAs(resp, dryRunOutput))
+			return
+		}
 		resp, err := c.ApplySchedulerWorkload(req)
 		cobra.CheckErr(err)
+		if resp.GetSuccess() {
+			c.RecordHistory(req)
+		}
 		printProto(resp)
 	},
 }
@@ -185,24 +200,30 @@ func init() {
 	schedulerApplyCmd.Flags().StringVar(&schedulerApplyID, "id", "", "Workload ID")
 	schedulerApplyCmd.Flags().StringVar(&schedulerApplyType, "type", "container", "Workload type: container|compose|vm")
 	schedulerApplyCmd.Flags().StringVar(&schedulerApplySpecFile, "spec-file", "", "Path to JSON spec file")
-	schedulerApplyCmd.Flags().StringVar(&schedulerApplyRevision, "revision", "rev-1", "Workload revision ID")
+	schedulerApplyCmd.Flags().StringVar(&schedulerApplyRevision, "revision", "", "Workload revision ID (default: content hash of the spec)")
 	schedulerApplyCmd.Flags().StringVar(&schedulerApplyDesired, "desired-state", "running", "Desired state: running|stopped")
 	cobra.CheckErr(schedulerApplyCmd.MarkFlagRequired("id"))
 	cobra.CheckErr(schedulerApplyCmd.MarkFlagRequired("spec-file"))
 
 	schedulerApplyContainerCmd.Flags().StringVar(&schedulerApplyID, "id", "", "Workload ID")
 	schedulerApplyContainerCmd.Flags().StringVar(&schedulerApplySpecFile, "spec-file", "", "Path to JSON spec file")
-	schedulerApplyContainerCmd.Flags().StringVar(&schedulerApplyRevision, "revision", "rev-1", "Workload revision ID")
+	schedulerApplyContainerCmd.Flags().StringVar(&schedulerApplyRevision, "revision", "", "Workload revision ID (default: content hash of the spec)")
 	schedulerApplyContainerCmd.Flags().StringVar(&schedulerApplyDesired, "desired-state", "running", "Desired state: running|stopped")
 	cobra.CheckErr(schedulerApplyContainerCmd.MarkFlagRequired("id"))
 	cobra.CheckErr(schedulerApplyContainerCmd.MarkFlagRequired("spec-file"))
 
 	schedulerApplyVMCmd.Flags().StringVar(&schedulerApplyID, "id", "", "Workload ID")
 	schedulerApplyVMCmd.Flags().StringVar(&schedulerApplySpecFile, "spec-file", "", "Path to JSON spec file")
-	schedulerApplyVMCmd.Flags().StringVar(&schedulerApplyRevision, "revision", "rev-1", "Workload revision ID")
+	schedulerApplyVMCmd.Flags().StringVar(&schedulerApplyRevision, "revision", "", "Workload revision ID (default: content hash of the spec)")
 	schedulerApplyVMCmd.Flags().StringVar(&schedulerApplyDesired, "desired-state", "running", "Desired state: running|stopped")
 	cobra.CheckErr(schedulerApplyVMCmd.MarkFlagRequired("id"))
 	cobra.CheckErr(schedulerApplyVMCmd.MarkFlagRequired("spec-file"))
+	addSpecRenderFlags(schedulerApplyCmd)
+	addSpecRenderFlags(schedulerApplyContainerCmd)
+	addSpecRenderFlags(schedulerApplyVMCmd)
+	addDryRunFlags(schedulerApplyCmd)
+	addDryRunFlags(schedulerApplyContainerCmd)
+	addDryRunFlags(schedulerApplyVMCmd)
 
 	schedulerDeleteWorkloadCmd.Flags().StringVar(&schedulerWorkloadID, "workload-id", "", "Workload ID")
 	schedulerRetryWorkloadCmd.Flags().StringVar(&schedulerWorkloadID, "workload-id", "", "Workload ID")
@@ -220,11 +241,16 @@ func init() {
 }
 
 func buildSchedulerWorkloadSpec(typ, specFile string) (*controlv1.WorkloadSpec, error) {
-	body, err := os.ReadFile(specFile)
+	body, err := readSpecFile(specFile)
 	if err != nil {
 		return nil, fmt.Errorf("read spec file: %w", err)
 	}
+	return parseSchedulerWorkloadSpec(typ, body)
+}
 
+// parseSchedulerWorkloadSpec parses an already rendered spec body of the given
+// workload type, accepting either the scheduler or the agent spec shape.
+func parseSchedulerWorkloadSpec(typ string, body []byte) (*controlv1.WorkloadSpec, error) {
 	t := strings.ToLower(strings.TrimSpace(typ))
 	switch t {
 	case "container", "docker-container":
@@ -349,7 +375,7 @@ func parseAgentVMSpecForScheduler(body []byte) (*controlv1.WorkloadSpec, error)
 		metadata[k] = v
 	}
 	// Preserve full VM disk/network details for scheduler paths that still use reduced control VM disk schema.
-	metadata["persys.vm_spec_b64"] = base64.StdEncoding.EncodeToString(body)
+	metadata[vmSpecMetadataKey] = base64.StdEncoding.EncodeToString(body)
 
 	cloudInit := &controlv1.CloudInitConfig{}
 	if vm.GetCloudInitConfig() != nil {
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/persys-dev/persysctl/internal/ingestion"
+	"github.com/spf13/cobra"
+)
+
+var (
+	specValuesFiles []string
+	specSetValues   []string
+	specSetStrings  []string
+	specPatchFiles  []string
+)
+
+// addSpecRenderFlags registers the templating/overlay flags on commands that
+// read a spec file.
+func addSpecRenderFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&specValuesFiles, "values", nil, "Values file for spec templating (repeatable, later files win)")
+	cmd.Flags().StringArrayVar(&specSetValues, "set", nil, "Template value override key=value, dotted keys allowed (repeatable)")
+	cmd.Flags().StringArrayVar(&specSetStrings, "set-string", nil, "Template value override key=value that is always a string (repeatable)")
+	cmd.Flags().StringArrayVar(&specPatchFiles, "patch", nil, "Overlay patch file: strategic merge or JSON Patch (repeatable, applied in order)")
+}
+
+// readSpecFile reads a spec file and resolves templating and overlays.
+// Templates are only rendered when --values, --set or --set-string is given, so plain
+// specs containing "{{" pass through untouched.
+func readSpecFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	render := len(specValuesFiles) > 0 || len(specSetValues) > 0 || len(specSetStrings) > 0
+	var values ingestion.Values
+	if render {
+		values, err = ingestion.LoadValues(specValuesFiles, specSetValues, specSetStrings)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = ingestion.Render(data, values); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if len(specPatchFiles) == 0 {
+		return data, nil
+	}
+	patches := make([][]byte, 0, len(specPatchFiles))
+	for _, patchFile := range specPatchFiles {
+		patch, err := os.ReadFile(patchFile)
+		if err != nil {
+			return nil, err
+		}
+		if render {
+			if patch, err = ingestion.Render(patch, values); err != nil {
+				return nil, fmt.Errorf("%s: %w", patchFile, err)
+			}
+		}
+		patches = append(patches, patch)
+	}
+	return ingestion.ApplyPatches(data, patches...)
+}
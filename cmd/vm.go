@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/config"
+	"github.com/persys-dev/persysctl/internal/vmspec"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	vmCreateID        string
+	vmCreateFile      string
+	vmCreateName      string
+	vmCreateHostname  string
+	vmCreateVcpus     int32
+	vmCreateMemory    int64
+	vmCreateDisks     []string
+	vmCreateNetworks  []string
+	vmCreateSSHKeys   []string
+	vmCreateUsers     []string
+	vmCreatePackages  []string
+	vmCreateRuncmd    []string
+	vmCreateMetadata  map[string]string
+	vmCreateDesired   string
+	vmCreateRevision  string
+	vmCreatePrintSpec bool
+)
+
+var vmCmd = &cobra.Command{
+	Use:   "vm",
+	Short: "Manage virtual machine workloads",
+}
+
+var vmCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a VM workload with generated cloud-init",
+	Long: `Builds a VM spec from flags and/or a YAML file (-f), generates its cloud-init
+config (user_data, meta_data and network_config), validates disks and MAC
+addresses, and applies it through the scheduler or, with --grpc-target agent,
+directly to the agent. Flags override values from the file.
+
+  --disk    PATH[,format=qcow2|raw|iso][,size=GB][,device=vda][,type=disk|cdrom][,boot]
+  --network NAME[,mac=52:54:00:12:34:56][,ip=10.0.0.10/24][,gateway=10.0.0.1][,dns=1.1.1.1 8.8.8.8]
+  --user    NAME[,groups=sudo:docker][,sudo=ALL=(ALL) NOPASSWD:ALL][,shell=/bin/bash]
+  --ssh-key a public key, or the path to a .pub file
+
+--print-spec prints the generated compute-agent VM spec instead of applying
+it; the output works with workload schedule --type vm --spec-file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		vm := &vmspec.VM{}
+		if vmCreateFile != "" {
+			loaded, err := vmspec.LoadFile(vmCreateFile)
+			cobra.CheckErr(err)
+			vm = loaded
+		}
+		cobra.CheckErr(applyVMFlags(cmd, vm))
+		if vmCreateID == "" {
+			vmCreateID = strings.ToLower(vm.Name)
+		}
+		if vm.Name == "" {
+			vm.Name = vmCreateID
+		}
+		if vmCreateID == "" {
+			cobra.CheckErr(fmt.Errorf("--id or --name is required"))
+		}
+
+		body, err := vm.AgentSpec(vmCreateID)
+		cobra.CheckErr(err)
+		if vmCreatePrintSpec {
+			fmt.Println(string(body))
+			return
+		}
+
+		cfg := config.GetConfig()
+		target := strings.TrimSpace(cfg.GRPCTarget)
+		if target == "" {
+			target = "scheduler"
+		}
+		req, err := buildVMApplyRequest(target, body)
+		cobra.CheckErr(err)
+
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		if mode == "client" {
+			cobra.CheckErr(printProtoAs(req, dryRunOutput))
+			return
+		}
+		if target == "agent" && cfg.Transport != "grpc" {
+			cobra.CheckErr(fmt.Errorf("--grpc-target agent requires --transport grpc"))
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		out := map[string]any{
+			"target":      target,
+			"transport":   cfg.Transport,
+			"workload_id": vmCreateID,
+		}
+		switch r := req.(type) {
+		case *controlv1.ApplyWorkloadRequest:
+			if mode == "server" {
+				resp, err := c.PreviewSchedulerWorkload(r)
+				cobra.CheckErr(err)
+				cobra.CheckErr(printProtoAs(resp, dryRunOutput))
+				return
+			}
+			resp, err := c.ApplySchedulerWorkload(r)
+			cobra.CheckErr(err)
+			if resp.GetSuccess() {
+				c.RecordHistory(r)
+			}
+			out["accepted"] = resp.GetSuccess()
+			if !resp.GetSuccess() {
+				out["error_message"] = resp.GetErrorMessage()
+				out["failure_reason"] = resp.GetFailureReason().String()
+			}
+			if getResp, err := c.GetWorkload(vmCreateID); err == nil && getResp.GetWorkload() != nil {
+				w := getResp.GetWorkload()
+				out["status"] = w.GetStatus()
+				out["assigned_node_id"] = w.GetAssignedNodeId()
+				out["revision_id"] = w.GetRevisionId()
+			}
+		case *agentv1.ApplyWorkloadRequest:
+			if mode == "server" {
+				cobra.CheckErr(fmt.Errorf("server-side dry-run is only available for scheduler targets; use --dry-run=client"))
+			}
+			resp, err := c.ApplyAgentWorkload(r)
+			cobra.CheckErr(err)
+			out["applied"] = resp.GetApplied()
+			if !resp.GetApplied() {
+				out["message"] = resp.GetMessage()
+			}
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vmCmd)
+	vmCmd.AddCommand(vmCreateCmd)
+
+	f := vmCreateCmd.Flags()
+	f.StringVar(&vmCreateID, "id", "", "Workload ID (default: the VM name)")
+	f.StringVarP(&vmCreateFile, "file", "f", "", "VM description file (YAML or JSON)")
+	f.StringVar(&vmCreateName, "name", "", "VM name")
+	f.StringVar(&vmCreateHostname, "hostname", "", "Guest hostname (default: the VM name)")
+	f.Int32Var(&vmCreateVcpus, "vcpus", 1, "Virtual CPUs")
+	f.Int64Var(&vmCreateMemory, "memory", 1024, "Memory in MB")
+	f.StringArrayVar(&vmCreateDisks, "disk", nil, "Disk: PATH[,format=..][,size=GB][,device=..][,type=..][,boot] (repeatable)")
+	f.StringArrayVar(&vmCreateNetworks, "network", nil, "Network: NAME[,mac=..][,ip=CIDR][,gateway=..][,dns=..] (repeatable)")
+	f.StringArrayVar(&vmCreateSSHKeys, "ssh-key", nil, "SSH public key or .pub file for the default user (repeatable)")
+	f.StringArrayVar(&vmCreateUsers, "user", nil, "Guest user: NAME[,groups=a:b][,sudo=..][,shell=..] (repeatable)")
+	f.StringArrayVar(&vmCreatePackages, "package", nil, "Package to install on first boot (repeatable)")
+	f.StringArrayVar(&vmCreateRuncmd, "runcmd", nil, "Command to run on first boot (repeatable)")
+	f.StringToStringVar(&vmCreateMetadata, "metadata", nil, "Workload metadata key=value")
+	f.StringVar(&vmCreateDesired, "desired-state", "running", "Desired state: running|stopped")
+	f.StringVar(&vmCreateRevision, "revision", "", "Workload revision ID (default: content hash of the spec)")
+	f.BoolVar(&vmCreatePrintSpec, "print-spec", false, "Print the generated agent VM spec and exit")
+	addDryRunFlags(vmCreateCmd)
+}
+
+// applyVMFlags overlays the flags that were set on vm.
+func applyVMFlags(cmd *cobra.Command, vm *vmspec.VM) error {
+	flags := cmd.Flags()
+	if flags.Changed("name") || vm.Name == "" {
+		vm.Name = vmCreateName
+	}
+	if flags.Changed("hostname") {
+		vm.Hostname = vmCreateHostname
+	}
+	if flags.Changed("vcpus") || vm.Vcpus == 0 {
+		vm.Vcpus = vmCreateVcpus
+	}
+	if flags.Changed("memory") || vm.MemoryMB == 0 {
+		vm.MemoryMB = vmCreateMemory
+	}
+	for _, s := range vmCreateDisks {
+		d, err := parseVMDisk(s)
+		if err != nil {
+			return err
+		}
+		vm.Disks = append(vm.Disks, d)
+	}
+	for _, s := range vmCreateNetworks {
+		n, err := parseVMNetwork(s)
+		if err != nil {
+			return err
+		}
+		vm.Networks = append(vm.Networks, n)
+	}
+	for _, s := range vmCreateUsers {
+		u, err := parseVMUser(s)
+		if err != nil {
+			return err
+		}
+		vm.Users = append(vm.Users, u)
+	}
+	for _, k := range vmCreateSSHKeys {
+		key, err := readSSHKey(k)
+		if err != nil {
+			return err
+		}
+		vm.SSHAuthorizedKeys = append(vm.SSHAuthorizedKeys, key)
+	}
+	vm.Packages = append(vm.Packages, vmCreatePackages...)
+	vm.Runcmd = append(vm.Runcmd, vmCreateRuncmd...)
+	if len(vmCreateMetadata) > 0 && vm.Metadata == nil {
+		vm.Metadata = map[string]string{}
+	}
+	for k, v := range vmCreateMetadata {
+		vm.Metadata[k] = v
+	}
+	return nil
+}
+
+// splitVMOption splits "HEAD,key=value,flag" into its head and options.
+// Values may contain "=" but not ",".
+func splitVMOption(s string) (string, map[string]string) {
+	parts := strings.Split(s, ",")
+	opts := map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		opts[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(parts[0]), opts
+}
+
+func parseVMDisk(s string) (vmspec.Disk, error) {
+	path, opts := splitVMOption(s)
+	d := vmspec.Disk{Path: path, Format: opts["format"], Device: opts["device"], Type: opts["type"]}
+	for k, v := range opts {
+		switch k {
+		case "format", "device", "type":
+		case "size":
+			size, err := strconv.ParseInt(strings.TrimSuffix(strings.ToUpper(v), "G"), 10, 64)
+			if err != nil {
+				return d, fmt.Errorf("--disk %q: invalid size %q", s, v)
+			}
+			d.SizeGB = size
+		case "boot":
+			d.Boot = v == "" || v == "true"
+		default:
+			return d, fmt.Errorf("--disk %q: unknown option %q", s, k)
+		}
+	}
+	return d, nil
+}
+
+func parseVMNetwork(s string) (vmspec.Network, error) {
+	name, opts := splitVMOption(s)
+	n := vmspec.Network{Network: name}
+	for k, v := range opts {
+		switch k {
+		case "mac":
+			n.MAC = v
+		case "ip":
+			n.IP = v
+		case "gateway":
+			n.Gateway = v
+		case "dns":
+			n.Nameservers = append(n.Nameservers, strings.Fields(v)...)
+		default:
+			return n, fmt.Errorf("--network %q: unknown option %q", s, k)
+		}
+	}
+	return n, nil
+}
+
+func parseVMUser(s string) (vmspec.User, error) {
+	name, opts := splitVMOption(s)
+	u := vmspec.User{Name: name}
+	for k, v := range opts {
+		switch k {
+		case "groups":
+			u.Groups = strings.Split(v, ":")
+		case "sudo":
+			u.Sudo = v
+		case "shell":
+			u.Shell = v
+		default:
+			return u, fmt.Errorf("--user %q: unknown option %q", s, k)
+		}
+	}
+	return u, nil
+}
+
+// readSSHKey returns k itself when it looks like a public key, otherwise the
+// contents of the file it names.
+func readSSHKey(k string) (string, error) {
+	if strings.HasPrefix(k, "ssh-") || strings.HasPrefix(k, "ecdsa-") || strings.HasPrefix(k, "sk-") {
+		return strings.TrimSpace(k), nil
+	}
+	data, err := os.ReadFile(k)
+	if err != nil {
+		return "", fmt.Errorf("--ssh-key: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// buildVMApplyRequest wraps a generated agent VM spec for target.
+func buildVMApplyRequest(target string, body []byte) (proto.Message, error) {
+	switch target {
+	case "scheduler":
+		spec, err := parseAgentVMSpecForScheduler(body)
+		if err != nil {
+			return nil, err
+		}
+		return &controlv1.ApplyWorkloadRequest{
+			WorkloadId:   vmCreateID,
+			RevisionId:   revisionFor(vmCreateRevision, spec),
+			DesiredState: normalizeDesiredState(vmCreateDesired),
+			Spec:         spec,
+		}, nil
+	case "agent":
+		return buildAgentApplyRequest(vmCreateID, "vm", body, vmCreateRevision, vmCreateDesired)
+	default:
+		return nil, fmt.Errorf("unsupported grpc target %q (expected scheduler or agent)", target)
+	}
+}
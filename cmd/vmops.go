@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/agentstream"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/history"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// vmSpecMetadataKey carries the full agent VM spec through the scheduler's
+// reduced VM schema (see parseAgentVMSpecForScheduler).
+const vmSpecMetadataKey = "persys.vm_spec_b64"
+
+var (
+	vmSnapshotName        string
+	vmSnapshotDescription string
+	vmSnapshotMemory      bool
+	vmSnapshotOutput      string
+	vmRebootForce         bool
+	vmRebootFallback      bool
+	vmResizeVcpus         int32
+	vmResizeMemory        int64
+	vmConsoleReadOnly     bool
+)
+
+var vmSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Create, list and revert VM snapshots (experimental)",
+	Long: `Snapshots are taken by the compute-agent hosting the VM through its VM
+lifecycle service. Agents without it report the operation as unsupported;
+there is no scheduler fallback for snapshots.
+
+Experimental: the service is persysctl.experimental.v1.VMLifecycle, which is
+not part of the persys-cloud agent API yet.`,
+}
+
+var vmSnapshotCreateCmd = &cobra.Command{
+	Use:   "create <id>",
+	Short: "Snapshot a VM workload",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+		cobra.CheckErr(requireVMWorkload(c, args[0]))
+
+		name := vmSnapshotName
+		if name == "" {
+			name = args[0] + "-" + time.Now().UTC().Format("20060102-150405")
+		}
+		snap, err := c.CreateVMSnapshot(agentstream.SnapshotRequest{
+			WorkloadID:    args[0],
+			Name:          name,
+			Description:   vmSnapshotDescription,
+			IncludeMemory: vmSnapshotMemory,
+		})
+		cobra.CheckErr(vmOperationError("snapshots", args[0], err))
+		data, err := json.MarshalIndent(snap, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+var vmSnapshotListCmd = &cobra.Command{
+	Use:   "list <id>",
+	Short: "List the snapshots of a VM workload",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+		cobra.CheckErr(requireVMWorkload(c, args[0]))
+
+		resp, err := c.ListVMSnapshots(args[0])
+		cobra.CheckErr(vmOperationError("snapshots", args[0], err))
+		if strings.EqualFold(vmSnapshotOutput, "json") {
+			data, err := json.MarshalIndent(resp.Snapshots, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "NAME\tSTATE\tCREATED\tSIZE\tMEMORY\tDESCRIPTION")
+		for _, s := range resp.Snapshots {
+			created := "-"
+			if !s.CreatedAt.IsZero() {
+				created = s.CreatedAt.UTC().Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n", s.Name, valueOrDash(s.State), created,
+				formatBytes(float64(s.SizeBytes)), s.HasMemory, valueOrDash(s.Description))
+		}
+		cobra.CheckErr(tw.Flush())
+	},
+}
+
+var vmSnapshotRevertCmd = &cobra.Command{
+	Use:   "revert <id> <snapshot>",
+	Short: "Revert a VM workload to a snapshot",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+		cobra.CheckErr(requireVMWorkload(c, args[0]))
+
+		res, err := c.RevertVMSnapshot(agentstream.SnapshotRequest{WorkloadID: args[0], Name: args[1]})
+		cobra.CheckErr(vmOperationError("snapshots", args[0], err))
+		if !res.Accepted {
+			cobra.CheckErr(fmt.Errorf("revert %s to %s rejected: %s", args[0], args[1], res.Message))
+		}
+		printVMResult(args[0], "agent", res.Message)
+	},
+}
+
+var vmRebootCmd = &cobra.Command{
+	Use:   "reboot <id>",
+	Short: "Reboot a VM workload",
+	Long: `Asks the agent to reboot the VM (ACPI, or a reset with --force). When the agent
+does not support reboots, the VM is restarted through the scheduler instead
+(desired state Stopped, then Running) unless --fallback=false.
+
+Experimental: agent reboots use persysctl.experimental.v1.VMLifecycle, which
+is not part of the persys-cloud agent API yet.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+		cobra.CheckErr(requireVMWorkload(c, args[0]))
+
+		res, err := c.RebootVM(agentstream.RebootRequest{WorkloadID: args[0], Force: vmRebootForce})
+		if errors.Is(err, agentstream.ErrUnsupported) && vmRebootFallback {
+			_, _ = fmt.Fprintf(os.Stderr, "agent does not support reboot, restarting %s through the scheduler\n", args[0])
+			ok, msg, err := batchRestart(c, args[0])
+			cobra.CheckErr(err)
+			if !ok {
+				cobra.CheckErr(fmt.Errorf("restart %s rejected: %s", args[0], msg))
+			}
+			printVMResult(args[0], "scheduler-restart", msg)
+			return
+		}
+		cobra.CheckErr(vmOperationError("reboots", args[0], err))
+		if !res.Accepted {
+			cobra.CheckErr(fmt.Errorf("reboot %s rejected: %s", args[0], res.Message))
+		}
+		printVMResult(args[0], "agent", res.Message)
+	},
+}
+
+var vmResizeCmd = &cobra.Command{
+	Use:   "resize <id>",
+	Short: "Change the vCPUs and memory of a VM workload",
+	Long: `Re-applies the VM's latest recorded spec as a new revision with the new
+vCPU count and/or memory size. Whether the agent applies the change live or
+by restarting the VM is up to the agent. Requires a spec recorded by
+persysctl in the local revision history.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("vcpus") && !cmd.Flags().Changed("memory") {
+			cobra.CheckErr(fmt.Errorf("--vcpus or --memory is required"))
+		}
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		req, before, err := buildVMResizeRequest(c, args[0], vmResizeVcpus, vmResizeMemory)
+		cobra.CheckErr(err)
+		switch mode {
+		case "client":
+			cobra.CheckErr(printProtoAs(req, dryRunOutput))
+			return
+		case "server":
+			resp, err := c.PreviewSchedulerWorkload(req)
+			cobra.CheckErr(err)
+			cobra.CheckErr(printProtoAs(resp, dryRunOutput))
+			return
+		}
+
+		resp, err := c.ApplySchedulerWorkload(req)
+		cobra.CheckErr(err)
+		if !resp.GetSuccess() {
+			cobra.CheckErr(fmt.Errorf("resize %s rejected: %s", args[0], resp.GetErrorMessage()))
+		}
+		// Later resizes start from the latest recorded spec.
+		c.RecordHistory(req)
+		vm := req.GetSpec().GetVm()
+		data, err := json.MarshalIndent(map[string]any{
+			"workloadId": args[0],
+			"revisionId": req.GetRevisionId(),
+			"from":       map[string]any{"vcpus": before.GetVcpus(), "memoryMb": before.GetMemoryMb()},
+			"to":         map[string]any{"vcpus": vm.GetVcpus(), "memoryMb": vm.GetMemoryMb()},
+		}, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+var vmConsoleCmd = &cobra.Command{
+	Use:   "console <id>",
+	Short: "Connect to a VM's serial console (experimental; Ctrl-] detaches)",
+	Long: `Attaches to the VM's serial console through the agent's Attach stream. When
+the agent does not support interactive attach, or with --read-only, the
+serial console log is followed instead.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !vmConsoleReadOnly {
+			code, err := runWorkloadSession(agentstream.ExecStart{
+				WorkloadID: args[0],
+				TTY:        term.IsTerminal(int(os.Stdin.Fd())),
+				Stdin:      true,
+			}, true)
+			if err == nil {
+				if code != 0 {
+					os.Exit(code)
+				}
+				return
+			}
+			if !errors.Is(err, agentstream.ErrUnsupported) {
+				cobra.CheckErr(err)
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "interactive console not supported by the agent, following the serial console log (read-only, Ctrl-C to stop)\n")
+		}
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err = c.StreamWorkloadLogs(ctx, agentstream.LogsRequest{WorkloadID: args[0], Follow: true, TailLines: 100}, func(chunk *agentstream.LogChunk) error {
+			_, err := os.Stdout.Write(chunk.Data)
+			return err
+		})
+		if errors.Is(err, agentstream.ErrUnsupported) {
+			cobra.CheckErr(fmt.Errorf("the serial console of %s is not available: %w", args[0], err))
+		}
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	vmCmd.AddCommand(vmSnapshotCmd)
+	vmCmd.AddCommand(vmRebootCmd)
+	vmCmd.AddCommand(vmResizeCmd)
+	vmCmd.AddCommand(vmConsoleCmd)
+	vmSnapshotCmd.AddCommand(vmSnapshotCreateCmd)
+	vmSnapshotCmd.AddCommand(vmSnapshotListCmd)
+	vmSnapshotCmd.AddCommand(vmSnapshotRevertCmd)
+
+	vmSnapshotCreateCmd.Flags().StringVar(&vmSnapshotName, "name", "", "Snapshot name (default: <id>-<UTC timestamp>)")
+	vmSnapshotCreateCmd.Flags().StringVar(&vmSnapshotDescription, "description", "", "Snapshot description")
+	vmSnapshotCreateCmd.Flags().BoolVar(&vmSnapshotMemory, "memory", false, "Include the running guest's memory state")
+	vmSnapshotListCmd.Flags().StringVarP(&vmSnapshotOutput, "output", "o", "table", "Output format: table|json")
+
+	vmRebootCmd.Flags().BoolVar(&vmRebootForce, "force", false, "Reset the VM instead of asking the guest to reboot")
+	vmRebootCmd.Flags().BoolVar(&vmRebootFallback, "fallback", true, "Restart through the scheduler when the agent does not support reboots")
+
+	vmResizeCmd.Flags().Int32Var(&vmResizeVcpus, "vcpus", 0, "New number of virtual CPUs")
+	vmResizeCmd.Flags().Int64Var(&vmResizeMemory, "memory", 0, "New memory size in MB")
+	addDryRunFlags(vmResizeCmd)
+
+	vmConsoleCmd.Flags().BoolVar(&vmConsoleReadOnly, "read-only", false, "Follow the serial console log instead of attaching")
+}
+
+// requireVMWorkload fails when the scheduler knows id as a non-VM workload.
+// Lookup errors are left to the operation itself.
+func requireVMWorkload(c *client.Client, id string) error {
+	resp, err := c.GetWorkload(id)
+	if err != nil || resp.GetWorkload() == nil {
+		return nil
+	}
+	if typ := resp.GetWorkload().GetType(); typ != "" && !strings.EqualFold(typ, "vm") {
+		return fmt.Errorf("workload %s is a %s workload, not a vm", id, typ)
+	}
+	return nil
+}
+
+// vmOperationError explains unsupported VM operations.
+func vmOperationError(op, id string, err error) error {
+	if errors.Is(err, agentstream.ErrUnsupported) {
+		return fmt.Errorf("VM %s are not supported by the agent hosting %s; upgrade the compute-agent: %w", op, id, err)
+	}
+	return err
+}
+
+func printVMResult(id, method, message string) {
+	out := map[string]any{"workloadId": id, "method": method, "accepted": true}
+	if message != "" {
+		out["message"] = message
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	cobra.CheckErr(err)
+	fmt.Println(string(data))
+}
+
+// buildVMResizeRequest returns the apply request for id's latest recorded
+// spec with vcpus and memoryMB (when non-zero) applied, and the VM spec it
+// started from.
+func buildVMResizeRequest(c *client.Client, id string, vcpus int32, memoryMB int64) (*controlv1.ApplyWorkloadRequest, *controlv1.VMSpec, error) {
+	if vcpus < 0 || memoryMB < 0 {
+		return nil, nil, fmt.Errorf("--vcpus and --memory must be positive")
+	}
+	entries, err := c.History().List(id)
+	if err != nil || len(entries) == 0 {
+		return nil, nil, fmt.Errorf("no locally recorded spec for %s to resize; apply it with persysctl first", id)
+	}
+	latest := entries[len(entries)-1]
+	desired := latest.DesiredState
+	if resp, err := c.GetWorkload(id); err == nil && resp.GetWorkload().GetDesiredState() != "" {
+		desired = resp.GetWorkload().GetDesiredState()
+	}
+	return resizeVMRequest(latest, desired, vcpus, memoryMB)
+}
+
+// resizeVMRequest applies vcpus and memoryMB (when non-zero) to the spec of
+// entry, keeping the scheduler resources and the embedded agent spec in step.
+func resizeVMRequest(entry history.Entry, desired string, vcpus int32, memoryMB int64) (*controlv1.ApplyWorkloadRequest, *controlv1.VMSpec, error) {
+	id := entry.WorkloadID
+	spec := &controlv1.WorkloadSpec{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(entry.Spec, spec); err != nil {
+		return nil, nil, fmt.Errorf("decode recorded spec for %s: %w", id, err)
+	}
+	vm := spec.GetVm()
+	if vm == nil {
+		return nil, nil, fmt.Errorf("workload %s is not a vm", id)
+	}
+	before := &controlv1.VMSpec{Vcpus: vm.GetVcpus(), MemoryMb: vm.GetMemoryMb()}
+	if vcpus > 0 {
+		vm.Vcpus = vcpus
+	}
+	if memoryMB > 0 {
+		vm.MemoryMb = memoryMB
+	}
+	if vm.GetVcpus() == before.GetVcpus() && vm.GetMemoryMb() == before.GetMemoryMb() {
+		return nil, nil, fmt.Errorf("%s already has %d vCPUs and %d MB memory", id, vm.GetVcpus(), vm.GetMemoryMb())
+	}
+	if spec.Resources == nil {
+		spec.Resources = &controlv1.ResourceRequirements{}
+	}
+	spec.Resources.CpuMillicores = int64(vm.GetVcpus()) * 1000
+	spec.Resources.MemoryMb = vm.GetMemoryMb()
+	if err := resizeEmbeddedVMSpec(spec.GetMetadata(), vm.GetVcpus(), vm.GetMemoryMb()); err != nil {
+		return nil, nil, err
+	}
+
+	return &controlv1.ApplyWorkloadRequest{
+		WorkloadId:   id,
+		RevisionId:   revisionFor("", spec),
+		DesiredState: normalizeDesiredState(desired),
+		Spec:         spec,
+	}, before, nil
+}
+
+// resizeEmbeddedVMSpec updates the full agent VM spec carried in metadata so
+// the agent does not keep the old size.
+func resizeEmbeddedVMSpec(metadata map[string]string, vcpus int32, memoryMB int64) error {
+	encoded, ok := metadata[vmSpecMetadataKey]
+	if !ok {
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decode %s: %w", vmSpecMetadataKey, err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return fmt.Errorf("decode %s: %w", vmSpecMetadataKey, err)
+	}
+	// The spec was decoded with encoding/json, which matches keys
+	// case-insensitively; drop any spelling before setting the new size.
+	for k := range body {
+		if strings.EqualFold(k, "vcpus") || strings.EqualFold(k, "memory_mb") {
+			delete(body, k)
+		}
+	}
+	body["vcpus"] = vcpus
+	body["memory_mb"] = memoryMB
+	raw, err = json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	metadata[vmSpecMetadataKey] = base64.StdEncoding.EncodeToString(raw)
+	return nil
+}
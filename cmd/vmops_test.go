@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/history"
+)
+
+func embeddedVMSpec(t *testing.T, metadata map[string]string) map[string]any {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(metadata[vmSpecMetadataKey])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestResizeEmbeddedVMSpec(t *testing.T) {
+	spec := `{"Vcpus":2,"MEMORY_MB":2048,"os_image":"ubuntu-22.04","disks":[{"path":"/var/lib/vm/root.qcow2"}]}`
+	metadata := map[string]string{vmSpecMetadataKey: base64.StdEncoding.EncodeToString([]byte(spec)), "team": "ops"}
+	if err := resizeEmbeddedVMSpec(metadata, 4, 8192); err != nil {
+		t.Fatal(err)
+	}
+	body := embeddedVMSpec(t, metadata)
+	want := map[string]any{
+		"vcpus":     float64(4),
+		"memory_mb": float64(8192),
+		"os_image":  "ubuntu-22.04",
+		"disks":     []any{map[string]any{"path": "/var/lib/vm/root.qcow2"}},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Fatalf("embedded spec = %v, want %v", body, want)
+	}
+	if metadata["team"] != "ops" {
+		t.Fatal("other metadata changed")
+	}
+
+	if err := resizeEmbeddedVMSpec(map[string]string{}, 4, 8192); err != nil {
+		t.Fatalf("spec without embedded VM spec: %v", err)
+	}
+	if err := resizeEmbeddedVMSpec(map[string]string{vmSpecMetadataKey: "not base64!"}, 4, 8192); err == nil {
+		t.Fatal("expected error for invalid embedded spec")
+	}
+}
+
+func TestResizeVMRequest(t *testing.T) {
+	embedded := base64.StdEncoding.EncodeToString([]byte(`{"vcpus":2,"memory_mb":2048}`))
+	entry := history.Entry{
+		WorkloadID: "web-vm",
+		RevisionID: "r1",
+		Spec: json.RawMessage(`{"type":"vm","vm":{"vcpus":2,"memoryMb":"2048","osImage":"ubuntu-22.04"},` +
+			`"resources":{"cpuMillicores":"2000","memoryMb":"2048"},"metadata":{"` + vmSpecMetadataKey + `":"` + embedded + `"}}`),
+	}
+
+	req, before, err := resizeVMRequest(entry, "stopped", 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.GetVcpus() != 2 || before.GetMemoryMb() != 2048 {
+		t.Fatalf("before = %v", before)
+	}
+	vm := req.GetSpec().GetVm()
+	if req.GetWorkloadId() != "web-vm" || req.GetDesiredState() != "Stopped" || vm.GetVcpus() != 4 || vm.GetMemoryMb() != 2048 || vm.GetOsImage() != "ubuntu-22.04" {
+		t.Fatalf("request = %v", req)
+	}
+	if res := req.GetSpec().GetResources(); res.GetCpuMillicores() != 4000 || res.GetMemoryMb() != 2048 {
+		t.Fatalf("resources = %v", res)
+	}
+	if body := embeddedVMSpec(t, req.GetSpec().GetMetadata()); body["vcpus"] != float64(4) || body["memory_mb"] != float64(2048) {
+		t.Fatalf("embedded spec = %v", body)
+	}
+	if req.GetRevisionId() == "" || req.GetRevisionId() == "r1" {
+		t.Fatalf("revision = %q, want a new content revision", req.GetRevisionId())
+	}
+
+	if _, _, err := resizeVMRequest(entry, "Running", 2, 2048); err == nil || !strings.Contains(err.Error(), "already has") {
+		t.Fatalf("unchanged size error = %v", err)
+	}
+	container := history.Entry{WorkloadID: "web", Spec: json.RawMessage(`{"type":"container","container":{"image":"nginx"}}`)}
+	if _, _, err := resizeVMRequest(container, "Running", 4, 0); err == nil || !strings.Contains(err.Error(), "not a vm") {
+		t.Fatalf("container error = %v", err)
+	}
+}
@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/client"
+	"github.com/persys-dev/persysctl/internal/history"
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	volumeListOrphaned bool
+	volumeListAll      bool
+	volumeListDriver   string
+	volumeListNode     string
+	volumeOutput       string
+	volumeDeleteYes    bool
+	volumeDeleteWait   time.Duration
+	volumeDeleteNode   string
+)
+
+const (
+	volumeBound    = "Bound"
+	volumeOrphaned = "Orphaned"
+	volumeReleased = "Released"
+)
+
+// managedVolume is a managed volume declared by a workload spec, joined with
+// the scheduler's view of its owner.
+type managedVolume struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Driver        string    `json:"driver"`
+	SizeGB        int64     `json:"sizeGb"`
+	AccessMode    string    `json:"accessMode,omitempty"`
+	FsType        string    `json:"fsType,omitempty"`
+	MountPath     string    `json:"mountPath,omitempty"`
+	RetainPolicy  string    `json:"retainPolicy"`
+	Owner         string    `json:"ownerWorkload"`
+	OwnerStatus   string    `json:"ownerStatus,omitempty"`
+	NodeID        string    `json:"nodeId,omitempty"`
+	State         string    `json:"state"`
+	OwnerRevision string    `json:"ownerRevision,omitempty"`
+	AppliedAt     time.Time `json:"appliedAt,omitzero"`
+	// RemovedFromSpec is set when a later revision of the owner no longer
+	// declares the volume; OwnerRevision is then the last one that did.
+	RemovedFromSpec bool `json:"removedFromSpec,omitempty"`
+}
+
+type volumeInventory struct {
+	Volumes []managedVolume `json:"volumes"`
+	// Unknown lists live workloads without a locally recorded spec, whose
+	// volumes cannot be listed.
+	Unknown []string `json:"workloadsWithoutSpec,omitempty"`
+}
+
+var volumeCmd = &cobra.Command{
+	Use:   "volume",
+	Short: "Inspect and clean up managed volumes",
+	Long: `Managed volumes are declared in workload specs (managed_volumes). The
+scheduler does not list them, so persysctl builds the inventory from the specs
+in the local revision history and joins it with the scheduler's live
+workloads. A Retain volume is reported as Orphaned when its owner workload is
+gone or when a later revision of the owner no longer declares it.`,
+}
+
+var volumeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List managed volumes across workloads and nodes",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		inv, err := loadVolumeInventory(c)
+		cobra.CheckErr(err)
+
+		filtered := inv.Volumes[:0]
+		for _, v := range inv.Volumes {
+			switch {
+			case volumeListOrphaned && v.State != volumeOrphaned:
+			case !volumeListAll && !volumeListOrphaned && v.State == volumeReleased:
+			case volumeListDriver != "" && !strings.EqualFold(v.Driver, volumeListDriver):
+			case volumeListNode != "" && v.NodeID != volumeListNode:
+			default:
+				filtered = append(filtered, v)
+			}
+		}
+		inv.Volumes = filtered
+
+		if strings.EqualFold(volumeOutput, "json") {
+			data, err := json.MarshalIndent(inv, "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "VOLUME\tDRIVER\tSIZE\tACCESS\tRETAIN\tOWNER\tNODE\tSTATE")
+		for _, v := range inv.Volumes {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%dGi\t%s\t%s\t%s\t%s\t%s\n",
+				v.Name, v.Driver, v.SizeGB, valueOrDash(v.AccessMode), v.RetainPolicy,
+				v.Owner, valueOrDash(v.NodeID), v.State)
+		}
+		cobra.CheckErr(tw.Flush())
+		if len(inv.Unknown) > 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "\n%d live workloads have no locally recorded spec; their volumes are not listed: %s\n",
+				len(inv.Unknown), strings.Join(inv.Unknown, ", "))
+		}
+	},
+}
+
+var volumeGetCmd = &cobra.Command{
+	Use:   "get <workload>/<volume>",
+	Short: "Show a managed volume",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		inv, err := loadVolumeInventory(c)
+		cobra.CheckErr(err)
+		v, err := findVolume(inv, args[0])
+		cobra.CheckErr(err)
+		data, err := json.MarshalIndent(v, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+var volumeDeleteCmd = &cobra.Command{
+	Use:   "delete <workload>/<volume>",
+	Short: "Reclaim an orphaned volume",
+	Long: `Deletes an orphaned (retained) managed volume. There is no volume RPC, so the
+volume is reclaimed through its owner: the deleted owner workload is
+re-applied Stopped from the last recorded spec declaring the volume, with this
+volume's retain policy set to Delete, then deleted, which makes the agent
+remove the volume. Other retained volumes of the owner keep their Retain policy.
+
+Node-bound volumes (driver local) are only removed if the re-created owner
+lands on the node holding them. The history does not record that node, so it
+must be given with --node; when the scheduler places the owner elsewhere, the
+re-created owner is deleted again and the command fails. Volumes removed from
+the spec of a still running owner cannot be reclaimed this way.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		inv, err := loadVolumeInventory(c)
+		cobra.CheckErr(err)
+		v, err := findVolume(inv, args[0])
+		cobra.CheckErr(err)
+		switch v.State {
+		case volumeBound:
+			cobra.CheckErr(fmt.Errorf("volume %s is in use by workload %s (%s); remove it from the spec or delete the workload",
+				v.ID, v.Owner, v.OwnerStatus))
+		case volumeReleased:
+			cobra.CheckErr(fmt.Errorf("volume %s has retain policy %s and was removed with workload %s", v.ID, v.RetainPolicy, v.Owner))
+		}
+		if v.OwnerStatus != "" {
+			cobra.CheckErr(fmt.Errorf("volume %s was removed from the spec of workload %s, which is still %s; reclaiming it through the owner would delete the workload",
+				v.ID, v.Owner, v.OwnerStatus))
+		}
+		if volumeNodeBound(v.Driver) && volumeDeleteNode == "" {
+			cobra.CheckErr(fmt.Errorf("volume %s uses the node-bound %s driver; pass --node with the node that holds it", v.ID, v.Driver))
+		}
+
+		if !volumeDeleteYes {
+			ok, err := confirmBatch("reclaim volume "+v.Name+" through", []string{v.Owner})
+			cobra.CheckErr(err)
+			if !ok {
+				fmt.Println("aborted")
+				return
+			}
+		}
+		node, err := reclaimVolume(c, v, volumeDeleteNode)
+		cobra.CheckErr(err)
+		out := map[string]any{
+			"volume":  v.ID,
+			"owner":   v.Owner,
+			"deleted": true,
+		}
+		if node != "" {
+			out["nodeId"] = node
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(volumeCmd)
+	volumeCmd.AddCommand(volumeListCmd)
+	volumeCmd.AddCommand(volumeGetCmd)
+	volumeCmd.AddCommand(volumeDeleteCmd)
+
+	volumeListCmd.Flags().BoolVar(&volumeListOrphaned, "orphaned", false, "Only list orphaned volumes")
+	volumeListCmd.Flags().BoolVar(&volumeListAll, "all", false, "Include volumes already removed with their workload")
+	volumeListCmd.Flags().StringVar(&volumeListDriver, "driver", "", "Filter by driver: local|nfs|ceph-rbd")
+	volumeListCmd.Flags().StringVar(&volumeListNode, "node", "", "Filter by the owner's node")
+	volumeListCmd.Flags().StringVarP(&volumeOutput, "output", "o", "table", "Output format: table|json")
+
+	volumeDeleteCmd.Flags().BoolVarP(&volumeDeleteYes, "yes", "y", false, "Skip the confirmation prompt")
+	volumeDeleteCmd.Flags().StringVar(&volumeDeleteNode, "node", "", "Node holding the volume; required for node-bound (local) volumes")
+	volumeDeleteCmd.Flags().DurationVar(&volumeDeleteWait, "timeout", 90*time.Second, "How long to wait for the owner to be re-created before deleting it")
+}
+
+// loadVolumeInventory joins the managed volumes of every recorded revision
+// with the scheduler's live workloads.
+func loadVolumeInventory(c *client.Client) (*volumeInventory, error) {
+	latest, err := c.History().Latest()
+	if err != nil {
+		return nil, err
+	}
+	workloads, err := c.ListWorkloads("", "")
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]models.Workload{}
+	for _, w := range workloads {
+		if workloadDeleted(w) {
+			continue
+		}
+		live[w.ID] = w
+	}
+
+	inv := &volumeInventory{Volumes: []managedVolume{}}
+	recorded := map[string]bool{}
+	for _, e := range latest {
+		recorded[e.WorkloadID] = true
+		revisions, err := c.History().List(e.WorkloadID)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: %s: only the latest revision is scanned: %v\n", e.WorkloadID, err)
+			revisions = []history.Entry{e}
+		}
+		var owner *models.Workload
+		if w, ok := live[e.WorkloadID]; ok {
+			owner = &w
+		}
+		inv.Volumes = append(inv.Volumes, workloadVolumes(revisions, owner)...)
+	}
+	for id := range live {
+		if !recorded[id] {
+			inv.Unknown = append(inv.Unknown, id)
+		}
+	}
+	sort.Strings(inv.Unknown)
+	sort.Slice(inv.Volumes, func(i, j int) bool { return inv.Volumes[i].ID < inv.Volumes[j].ID })
+	return inv, nil
+}
+
+// workloadVolumes lists the volumes declared by any of a workload's
+// revisions (oldest first), each as of the last revision declaring it.
+// owner is the live workload, or nil when it is gone.
+func workloadVolumes(revisions []history.Entry, owner *models.Workload) []managedVolume {
+	var out []managedVolume
+	index := map[string]int{}
+	for i, e := range revisions {
+		spec := &controlv1.WorkloadSpec{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(e.Spec, spec); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: skip %s revision %s: decode recorded spec: %v\n", e.WorkloadID, e.RevisionID, err)
+			continue
+		}
+		current := i == len(revisions)-1
+		for _, mv := range specManagedVolumes(spec) {
+			v := managedVolume{
+				ID:            e.WorkloadID + "/" + mv.GetName(),
+				Name:          mv.GetName(),
+				Driver:        valueOrDefault(mv.GetDriver(), "local"),
+				SizeGB:        mv.GetSizeGb(),
+				AccessMode:    mv.GetAccessMode(),
+				FsType:        mv.GetFsType(),
+				MountPath:     mv.GetMountPath(),
+				RetainPolicy:  valueOrDefault(mv.GetRetainPolicy(), "Delete"),
+				Owner:         e.WorkloadID,
+				OwnerRevision: e.RevisionID,
+				AppliedAt:     e.AppliedAt,
+			}
+			switch {
+			case current && owner != nil:
+				v.State, v.OwnerStatus, v.NodeID = volumeBound, owner.Status, owner.NodeID
+			case strings.EqualFold(v.RetainPolicy, "Retain"):
+				v.State = volumeOrphaned
+			default:
+				v.State = volumeReleased
+			}
+			if !current {
+				v.RemovedFromSpec = true
+				if owner != nil {
+					v.OwnerStatus = owner.Status
+				}
+			}
+			if j, ok := index[v.ID]; ok {
+				out[j] = v
+			} else {
+				index[v.ID] = len(out)
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// volumeNodeBound reports whether volumes of driver live on a single node.
+func volumeNodeBound(driver string) bool {
+	return strings.EqualFold(driver, "local")
+}
+
+func specManagedVolumes(spec *controlv1.WorkloadSpec) []*controlv1.ManagedVolumeSpec {
+	switch {
+	case spec.GetContainer() != nil:
+		return spec.GetContainer().GetManagedVolumes()
+	case spec.GetVm() != nil:
+		return spec.GetVm().GetManagedVolumes()
+	}
+	return nil
+}
+
+func valueOrDefault(v, def string) string {
+	if strings.TrimSpace(v) == "" {
+		return def
+	}
+	return v
+}
+
+// findVolume resolves "<workload>/<volume>". A bare volume name is accepted
+// when it is unique.
+func findVolume(inv *volumeInventory, ref string) (*managedVolume, error) {
+	var matches []*managedVolume
+	for i := range inv.Volumes {
+		v := &inv.Volumes[i]
+		if v.ID == ref {
+			return v, nil
+		}
+		if v.Name == ref {
+			matches = append(matches, v)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("volume %s not found in recorded specs", ref)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	return nil, fmt.Errorf("volume name %s is ambiguous, use one of: %s", ref, strings.Join(ids, ", "))
+}
+
+// reclaimVolume re-creates v's owner, stopped, with v switched to the Delete
+// retain policy and deletes it again. For node-bound volumes the owner must
+// land on node; otherwise it is deleted without reporting the volume as
+// reclaimed. It returns the node the owner ran on.
+func reclaimVolume(c *client.Client, v *managedVolume, node string) (string, error) {
+	entry, err := c.History().Get(v.Owner, v.OwnerRevision)
+	if err != nil {
+		return "", err
+	}
+	spec, err := reclaimSpec(entry, v.Name)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.ApplySchedulerWorkload(&controlv1.ApplyWorkloadRequest{
+		WorkloadId:   v.Owner,
+		RevisionId:   revisionFor("", spec),
+		DesiredState: "Stopped",
+		Spec:         spec,
+	})
+	if err != nil {
+		return "", fmt.Errorf("re-create %s: %w", v.Owner, err)
+	}
+	if !resp.GetSuccess() {
+		return "", fmt.Errorf("re-create %s: %s", v.Owner, resp.GetErrorMessage())
+	}
+	if err := waitForSchedulerWorkloadStatus(c, v.Owner, "Stopped", volumeDeleteWait); err != nil {
+		return "", fmt.Errorf("%w; workload %s was re-created and still holds the volume, delete it to finish", err, v.Owner)
+	}
+	placed := ""
+	if get, err := c.GetWorkload(v.Owner); err == nil {
+		placed = get.GetWorkload().GetAssignedNodeId()
+	}
+	del, err := c.DeleteWorkload(v.Owner)
+	if err != nil {
+		return placed, fmt.Errorf("delete %s: %w", v.Owner, err)
+	}
+	if !del.GetSuccess() {
+		return placed, fmt.Errorf("delete %s: %s", v.Owner, del.GetErrorMessage())
+	}
+	if volumeNodeBound(v.Driver) && placed != node {
+		return placed, fmt.Errorf("the scheduler placed %s on %s, not %s; the re-created owner was deleted again but the volume on %s was not reclaimed",
+			v.Owner, valueOrDash(placed), node, node)
+	}
+	return placed, nil
+}
+
+func reclaimSpec(entry *history.Entry, volume string) (*controlv1.WorkloadSpec, error) {
+	spec := &controlv1.WorkloadSpec{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(entry.Spec, spec); err != nil {
+		return nil, fmt.Errorf("decode recorded spec for %s: %w", entry.WorkloadID, err)
+	}
+	for _, mv := range specManagedVolumes(spec) {
+		if mv.GetName() == volume {
+			mv.RetainPolicy = "Delete"
+			return spec, nil
+		}
+	}
+	return nil, fmt.Errorf("volume %s not found in recorded spec of %s", volume, entry.WorkloadID)
+}
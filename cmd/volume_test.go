@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/history"
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+func volumeRevision(rev, volumes string) history.Entry {
+	return history.Entry{
+		WorkloadID: "db",
+		RevisionID: rev,
+		Spec:       json.RawMessage(`{"type":"container","container":{"image":"postgres","managedVolumes":[` + volumes + `]}}`),
+	}
+}
+
+func TestWorkloadVolumes(t *testing.T) {
+	revisions := []history.Entry{
+		volumeRevision("r1", `{"name":"data","retainPolicy":"Retain"},{"name":"cache"},{"name":"logs","retainPolicy":"Retain","driver":"nfs"}`),
+		volumeRevision("r2", `{"name":"data","retainPolicy":"Retain","sizeGb":20},{"name":"logs","driver":"nfs"}`),
+		volumeRevision("r3", `{"name":"data","retainPolicy":"Retain","sizeGb":20}`),
+	}
+	type want struct {
+		state, revision, ownerStatus string
+		removed                      bool
+	}
+	check := func(t *testing.T, owner *models.Workload, expected map[string]want) {
+		t.Helper()
+		volumes := workloadVolumes(revisions, owner)
+		if len(volumes) != len(expected) {
+			t.Fatalf("got %d volumes, want %d: %+v", len(volumes), len(expected), volumes)
+		}
+		for _, v := range volumes {
+			w := expected[v.Name]
+			if v.State != w.state || v.OwnerRevision != w.revision || v.OwnerStatus != w.ownerStatus || v.RemovedFromSpec != w.removed {
+				t.Errorf("%s = %s at %s (owner %q, removed %v), want %+v", v.ID, v.State, v.OwnerRevision, v.OwnerStatus, v.RemovedFromSpec, w)
+			}
+		}
+	}
+
+	t.Run("live owner", func(t *testing.T) {
+		check(t, &models.Workload{ID: "db", Status: "Running", NodeID: "n1"}, map[string]want{
+			"data":  {volumeBound, "r3", "Running", false},
+			"cache": {volumeReleased, "r1", "Running", true},
+			// Retain in r1, but r2 switched it to Delete before removing it.
+			"logs": {volumeReleased, "r2", "Running", true},
+		})
+	})
+	t.Run("deleted owner", func(t *testing.T) {
+		check(t, nil, map[string]want{
+			"data":  {volumeOrphaned, "r3", "", false},
+			"cache": {volumeReleased, "r1", "", true},
+			"logs":  {volumeReleased, "r2", "", true},
+		})
+	})
+
+	// A Retain volume dropped by a later revision stays on the node.
+	revisions = revisions[:2]
+	revisions = append(revisions, volumeRevision("r3", `{"name":"logs","driver":"nfs"}`))
+	volumes := workloadVolumes(revisions, &models.Workload{ID: "db", Status: "Running"})
+	for _, v := range volumes {
+		if v.Name == "data" && (v.State != volumeOrphaned || !v.RemovedFromSpec || v.SizeGB != 20) {
+			t.Fatalf("removed Retain volume = %+v, want Orphaned as of r2", v)
+		}
+	}
+}
+
+func TestVolumeNodeBound(t *testing.T) {
+	if !volumeNodeBound("local") || !volumeNodeBound("LOCAL") || volumeNodeBound("nfs") || volumeNodeBound("ceph-rbd") {
+		t.Fatal("volumeNodeBound mismatch")
+	}
+}
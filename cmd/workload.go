@@ -3,15 +3,16 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/persys-dev/persysctl/internal/client"
 	"github.com/persys-dev/persysctl/internal/config"
 	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
 	"github.com/persys-dev/persysctl/internal/models"
 	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -40,7 +41,7 @@ var workloadScheduleCmd = &cobra.Command{
 		cfg := config.GetConfig()
 		var workload models.Workload
 		if len(args) > 0 {
-			data, err := os.ReadFile(args[0])
+			data, err := readSpecFile(args[0])
 			cobra.CheckErr(err)
 			cobra.CheckErr(json.Unmarshal(data, &workload))
 		} else {
@@ -85,26 +86,52 @@ var workloadScheduleCmd = &cobra.Command{
 			}
 		}
 
+		target := strings.TrimSpace(cfg.GRPCTarget)
+		if target == "" {
+			target = "scheduler"
+		}
+		mode, err := dryRunValue()
+		cobra.CheckErr(err)
+		if mode != "none" {
+			req, err := buildScheduleRequest(cfg, target, workload)
+			cobra.CheckErr(err)
+			if mode == "client" {
+				cobra.CheckErr(printProtoAs(req, dryRunOutput))
+				return
+			}
+			schedulerReq, ok := req.(*controlv1.ApplyWorkloadRequest)
+			if !ok {
+				cobra.CheckErr(fmt.Errorf("server-side dry-run is only available for scheduler targets; use --dry-run=client"))
+			}
+			c, _, err := newClientWithTrace()
+			cobra.CheckErr(err)
+			defer c.Close()
+			resp, err := c.PreviewSchedulerWorkload(schedulerReq)
+			cobra.CheckErr(err)
+			cobra.CheckErr(printProtoAs(resp, dryRunOutput))
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
 
 		if workloadSpecFile != "" {
-			target := strings.TrimSpace(cfg.GRPCTarget)
-			if target == "" {
-				target = "scheduler"
-			}
 			switch target {
 			case "scheduler":
 				spec, err := buildSchedulerWorkloadSpec(workload.Type, workloadSpecFile)
 				cobra.CheckErr(err)
-				resp, err := c.ApplySchedulerWorkload(&controlv1.ApplyWorkloadRequest{
+				req := &controlv1.ApplyWorkloadRequest{
 					WorkloadId:   workload.ID,
-					RevisionId:   workloadRevision,
+					RevisionId:   revisionFor(workloadRevision, spec),
 					DesiredState: normalizeDesiredState(workloadDesired),
 					Spec:         spec,
-				})
+				}
+				resp, err := c.ApplySchedulerWorkload(req)
 				cobra.CheckErr(err)
+				if resp.GetSuccess() {
+					c.RecordHistory(req)
+				}
 				out := map[string]any{
 					"target":      "scheduler",
 					"transport":   cfg.Transport,
@@ -172,6 +199,12 @@ var workloadListCmd = &cobra.Command{
 
 		workloads, err := c.ListWorkloads(workloadListNodeID, workloadListStatus)
 		cobra.CheckErr(err)
+		if workloadListGroupReplicas {
+			data, err := json.MarshalIndent(formatReplicaGroups(c, workloads), "", "  ")
+			cobra.CheckErr(err)
+			fmt.Println(string(data))
+			return
+		}
 		data, err := json.MarshalIndent(formatWorkloadsForOutput(workloads), "", "  ")
 		cobra.CheckErr(err)
 		fmt.Println(string(data))
@@ -196,6 +229,13 @@ var workloadDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete workload (scheduler gRPC)",
 	Run: func(cmd *cobra.Command, args []string) {
+		batch, err := batchRequested(workloadDeleteID)
+		cobra.CheckErr(err)
+		if batch {
+			runBatch("delete", batchDelete)
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
@@ -210,6 +250,13 @@ var workloadRetryCmd = &cobra.Command{
 	Use:   "retry",
 	Short: "Retry workload (scheduler gRPC)",
 	Run: func(cmd *cobra.Command, args []string) {
+		batch, err := batchRequested(workloadRetryID)
+		cobra.CheckErr(err)
+		if batch {
+			runBatch("retry", batchRetry)
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
@@ -224,6 +271,13 @@ var workloadStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Set desired state to running",
 	Run: func(cmd *cobra.Command, args []string) {
+		batch, err := batchRequested(workloadStartID)
+		cobra.CheckErr(err)
+		if batch {
+			runBatch("start", batchSetDesired("Running"))
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
@@ -240,6 +294,13 @@ var workloadStopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Set desired state to stopped",
 	Run: func(cmd *cobra.Command, args []string) {
+		batch, err := batchRequested(workloadStopID)
+		cobra.CheckErr(err)
+		if batch {
+			runBatch("stop", batchSetDesired("Stopped"))
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
@@ -256,6 +317,13 @@ var workloadRestartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "Stop then start workload",
 	Run: func(cmd *cobra.Command, args []string) {
+		batch, err := batchRequested(workloadRestartID)
+		cobra.CheckErr(err)
+		if batch {
+			runBatch("restart", batchRestart)
+			return
+		}
+
 		c, _, err := newClientWithTrace()
 		cobra.CheckErr(err)
 		defer c.Close()
@@ -297,7 +365,7 @@ func init() {
 	workloadScheduleCmd.Flags().String("compose", "", "Compose content (raw YAML or base64)")
 	workloadScheduleCmd.Flags().String("git-repo", "", "Git repository URL")
 	workloadScheduleCmd.Flags().String("git-branch", "main", "Git branch")
-	workloadScheduleCmd.Flags().String("git-token", "", "Git auth token")
+	workloadScheduleCmd.Flags().String("git-token", "", "Git token as a secret reference: vault:<path>#<key> or env:<NAME>")
 	workloadScheduleCmd.Flags().String("local-path", "", "Local Compose path")
 	workloadScheduleCmd.Flags().String("env", "", "Environment variables (key1=value1,key2=value2)")
 	workloadScheduleCmd.Flags().StringArray("ports", []string{}, "Ports to expose (e.g., 8080:80)")
@@ -305,24 +373,27 @@ func init() {
 	workloadScheduleCmd.Flags().String("network", "", "Network for the container")
 	workloadScheduleCmd.Flags().String("restart-policy", "no", "Restart policy")
 	workloadScheduleCmd.Flags().StringVar(&workloadSpecFile, "spec-file", "", "Path to JSON spec file (gRPC mode)")
-	workloadScheduleCmd.Flags().StringVar(&workloadRevision, "revision", "rev-1", "Workload revision ID (spec-file mode)")
+	workloadScheduleCmd.Flags().StringVar(&workloadRevision, "revision", "", "Workload revision ID (spec-file mode; default: content hash of the spec)")
 	workloadScheduleCmd.Flags().StringVar(&workloadDesired, "desired-state", "running", "Desired state: running|stopped (spec-file mode)")
+	addSpecRenderFlags(workloadScheduleCmd)
+	addDryRunFlags(workloadScheduleCmd)
 
 	workloadListCmd.Flags().StringVar(&workloadListStatus, "status", "", "Filter by status (scheduler target)")
 	workloadListCmd.Flags().StringVar(&workloadListNodeID, "node-id", "", "Filter by node id (scheduler target)")
+	workloadListCmd.Flags().BoolVar(&workloadListGroupReplicas, "group-replicas", false, "Group fanned-out replicas (<id>-rN) under their base workload")
 
 	workloadGetCmd.Flags().StringVar(&workloadGetID, "id", "", "Workload ID")
 	workloadDeleteCmd.Flags().StringVar(&workloadDeleteID, "id", "", "Workload ID")
+	addBatchFlags(workloadDeleteCmd)
 	workloadRetryCmd.Flags().StringVar(&workloadRetryID, "id", "", "Workload ID")
+	addBatchFlags(workloadRetryCmd)
 	workloadStartCmd.Flags().StringVar(&workloadStartID, "id", "", "Workload ID")
+	addBatchFlags(workloadStartCmd)
 	workloadStopCmd.Flags().StringVar(&workloadStopID, "id", "", "Workload ID")
+	addBatchFlags(workloadStopCmd)
 	workloadRestartCmd.Flags().StringVar(&workloadRestartID, "id", "", "Workload ID")
+	addBatchFlags(workloadRestartCmd)
 	cobra.CheckErr(workloadGetCmd.MarkFlagRequired("id"))
-	cobra.CheckErr(workloadDeleteCmd.MarkFlagRequired("id"))
-	cobra.CheckErr(workloadRetryCmd.MarkFlagRequired("id"))
-	cobra.CheckErr(workloadStartCmd.MarkFlagRequired("id"))
-	cobra.CheckErr(workloadStopCmd.MarkFlagRequired("id"))
-	cobra.CheckErr(workloadRestartCmd.MarkFlagRequired("id"))
 }
 
 func waitForSchedulerWorkloadStatus(
@@ -352,15 +423,44 @@ func waitForSchedulerWorkloadStatus(
 	return fmt.Errorf("timed out waiting for workload %s status=%s (last status=%s)", workloadID, expectedStatus, lastStatus)
 }
 
+// buildScheduleRequest returns the apply request workload schedule would
+// send to target, without dialing.
+func buildScheduleRequest(cfg config.Config, target string, workload models.Workload) (proto.Message, error) {
+	if workloadSpecFile == "" {
+		return client.BuildApplyRequest(cfg, workload)
+	}
+	switch target {
+	case "scheduler":
+		spec, err := buildSchedulerWorkloadSpec(workload.Type, workloadSpecFile)
+		if err != nil {
+			return nil, err
+		}
+		return &controlv1.ApplyWorkloadRequest{
+			WorkloadId:   workload.ID,
+			RevisionId:   revisionFor(workloadRevision, spec),
+			DesiredState: normalizeDesiredState(workloadDesired),
+			Spec:         spec,
+		}, nil
+	case "agent":
+		return buildAgentApplyRequestFromSpec(workload.ID, workload.Type, workloadSpecFile, workloadRevision, workloadDesired)
+	default:
+		return nil, fmt.Errorf("unsupported grpc target %q (expected scheduler or agent)", target)
+	}
+}
+
 func buildAgentApplyRequestFromSpec(id, typ, specFile, revision, desired string) (*agentv1.ApplyWorkloadRequest, error) {
-	specData, err := os.ReadFile(specFile)
+	specData, err := readSpecFile(specFile)
 	if err != nil {
 		return nil, err
 	}
+	return buildAgentApplyRequest(id, typ, specData, revision, desired)
+}
 
+// buildAgentApplyRequest builds an agent apply request from a rendered spec
+// body in the agent's own schema.
+func buildAgentApplyRequest(id, typ string, specData []byte, revision, desired string) (*agentv1.ApplyWorkloadRequest, error) {
 	req := &agentv1.ApplyWorkloadRequest{
 		Id:           id,
-		RevisionId:   revision,
 		DesiredState: desiredState(desired),
 		Spec:         &agentv1.WorkloadSpec{},
 	}
@@ -391,6 +491,7 @@ func buildAgentApplyRequestFromSpec(id, typ, specFile, revision, desired string)
 		return nil, fmt.Errorf("unsupported --type %q, use container|compose|vm", typ)
 	}
 
+	req.RevisionId = revisionFor(revision, req.Spec)
 	return req, nil
 }
 
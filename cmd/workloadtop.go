@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/models"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	workloadTopSort      string
+	workloadTopNode      string
+	workloadTopSelectors []string
+	workloadTopInterval  time.Duration
+	workloadTopOnce      bool
+	workloadTopOutput    string
+)
+
+// workloadTopRow is one workload's latest usage and the I/O rates derived
+// from the previous snapshot. Rates are zero until two snapshots with
+// different CollectedAt times have been seen.
+type workloadTopRow struct {
+	ID                   string    `json:"id"`
+	Type                 string    `json:"type"`
+	NodeID               string    `json:"nodeId,omitempty"`
+	Status               string    `json:"status"`
+	CPUPercent           float64   `json:"cpuPercent"`
+	MemoryBytes          int64     `json:"memoryBytes"`
+	NetRXBytesPerSec     float64   `json:"netRxBytesPerSec"`
+	NetTXBytesPerSec     float64   `json:"netTxBytesPerSec"`
+	DiskReadBytesPerSec  float64   `json:"diskReadBytesPerSec"`
+	DiskWriteBytesPerSec float64   `json:"diskWriteBytesPerSec"`
+	CollectedAt          time.Time `json:"collectedAt,omitempty"`
+
+	usage *models.WorkloadUsage
+}
+
+var workloadTopCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show live CPU, memory and I/O usage of workloads",
+	Run: func(cmd *cobra.Command, args []string) {
+		sortKey := strings.ToLower(strings.TrimSpace(workloadTopSort))
+		switch sortKey {
+		case "cpu", "mem", "memory":
+		default:
+			cobra.CheckErr(fmt.Errorf("invalid --sort %q (expected cpu|mem)", workloadTopSort))
+		}
+		selector, err := parseLabelSelector(workloadTopSelectors)
+		cobra.CheckErr(err)
+
+		c, _, err := newClientWithTrace()
+		cobra.CheckErr(err)
+		defer c.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		clearScreen := !workloadTopOnce && term.IsTerminal(int(os.Stdout.Fd())) && !strings.EqualFold(workloadTopOutput, "json")
+		prev := map[string]workloadTopRow{}
+		for first := true; ; first = false {
+			workloads, err := c.ListWorkloads(workloadTopNode, "")
+			if err != nil {
+				if first {
+					cobra.CheckErr(err)
+				}
+				// A transient failure should not end the view: leave the
+				// last snapshot on screen and try again on the next tick.
+				_, _ = fmt.Fprintf(os.Stderr, "refresh failed: %v\n", err)
+				if !waitWorkloadTop(ctx) {
+					return
+				}
+				continue
+			}
+
+			rows := make([]workloadTopRow, 0, len(workloads))
+			next := make(map[string]workloadTopRow, len(workloads))
+			for _, w := range workloads {
+				if workloadTopNode != "" && w.NodeID != workloadTopNode {
+					continue
+				}
+				if len(selector) > 0 && !selector.matches(workloadLabels(c, w)) {
+					continue
+				}
+				row := workloadTopRow{ID: w.ID, Type: w.Type, NodeID: w.NodeID, Status: w.Status}
+				if w.Usage != nil {
+					row.CPUPercent = w.Usage.CPUPercent
+					row.MemoryBytes = w.Usage.MemoryBytes
+					row.CollectedAt = w.Usage.CollectedAt
+					row.usage = w.Usage
+					if p, ok := prev[w.ID]; ok && p.usage != nil && !w.Usage.CollectedAt.After(p.CollectedAt) {
+						// The agent has not reported a new snapshot yet; keep
+						// the previous rates and baseline.
+						row = p
+						row.Status = w.Status
+					} else if ok {
+						applyUsageRates(&row, p.usage, w.Usage)
+					}
+					next[w.ID] = row
+				}
+				rows = append(rows, row)
+			}
+			prev = next
+			sortWorkloadTopRows(rows, sortKey)
+
+			if clearScreen {
+				fmt.Print("\033[H\033[2J")
+			}
+			cobra.CheckErr(printWorkloadTop(rows))
+			if workloadTopOnce || workloadTopInterval <= 0 || !waitWorkloadTop(ctx) {
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	workloadCmd.AddCommand(workloadTopCmd)
+
+	workloadTopCmd.Flags().StringVar(&workloadTopSort, "sort", "cpu", "Sort by: cpu|mem")
+	workloadTopCmd.Flags().StringVar(&workloadTopNode, "node", "", "Only show workloads assigned to this node")
+	workloadTopCmd.Flags().StringArrayVarP(&workloadTopSelectors, "selector", "l", nil, "Label selector: key=value, key!=value or key (repeatable and comma-separated; all must match)")
+	workloadTopCmd.Flags().DurationVar(&workloadTopInterval, "interval", 5*time.Second, "Refresh interval")
+	workloadTopCmd.Flags().BoolVar(&workloadTopOnce, "once", false, "Print a single snapshot and exit (I/O rates need two snapshots and are left at 0)")
+	workloadTopCmd.Flags().StringVarP(&workloadTopOutput, "output", "o", "table", "Output format: table|json")
+}
+
+// waitWorkloadTop sleeps for one refresh interval and reports whether the
+// view should refresh again.
+func waitWorkloadTop(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(workloadTopInterval):
+		return true
+	}
+}
+
+// applyUsageRates fills row's I/O rates from the counter deltas between prev
+// and cur. Counters that went backwards (a restarted workload) yield no rate.
+func applyUsageRates(row *workloadTopRow, prev, cur *models.WorkloadUsage) {
+	if prev == nil || cur == nil || prev.CollectedAt.IsZero() || !cur.CollectedAt.After(prev.CollectedAt) {
+		return
+	}
+	secs := cur.CollectedAt.Sub(prev.CollectedAt).Seconds()
+	rate := func(before, after int64) float64 {
+		if after < before {
+			return 0
+		}
+		return float64(after-before) / secs
+	}
+	row.NetRXBytesPerSec = rate(prev.NetRXBytes, cur.NetRXBytes)
+	row.NetTXBytesPerSec = rate(prev.NetTXBytes, cur.NetTXBytes)
+	row.DiskReadBytesPerSec = rate(prev.DiskReadBytes, cur.DiskReadBytes)
+	row.DiskWriteBytesPerSec = rate(prev.DiskWriteBytes, cur.DiskWriteBytes)
+}
+
+func sortWorkloadTopRows(rows []workloadTopRow, key string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if key == "cpu" {
+			if rows[i].CPUPercent != rows[j].CPUPercent {
+				return rows[i].CPUPercent > rows[j].CPUPercent
+			}
+		} else if rows[i].MemoryBytes != rows[j].MemoryBytes {
+			return rows[i].MemoryBytes > rows[j].MemoryBytes
+		}
+		return rows[i].ID < rows[j].ID
+	})
+}
+
+func printWorkloadTop(rows []workloadTopRow) error {
+	if strings.EqualFold(workloadTopOutput, "json") {
+		data, err := json.Marshal(map[string]any{
+			"time":      time.Now().UTC().Format(time.RFC3339),
+			"workloads": rows,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "WORKLOAD\tTYPE\tNODE\tSTATUS\tCPU%\tMEM\tNET RX/s\tNET TX/s\tDISK R/s\tDISK W/s")
+	for _, r := range rows {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1f\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Type, valueOrDash(r.NodeID), r.Status, r.CPUPercent, formatBytes(float64(r.MemoryBytes)),
+			formatBytes(r.NetRXBytesPerSec), formatBytes(r.NetTXBytesPerSec),
+			formatBytes(r.DiskReadBytesPerSec), formatBytes(r.DiskWriteBytesPerSec))
+	}
+	return tw.Flush()
+}
+
+// formatBytes renders n with a binary unit suffix.
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%.0fB", n)
+	}
+	units := []string{"Ki", "Mi", "Gi", "Ti"}
+	i := -1
+	for n >= unit && i < len(units)-1 {
+		n /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f%s", n, units[i])
+}
@@ -0,0 +1,119 @@
+// Package agentstream is the client side of the compute-agent streaming
+// service used for logs, exec/attach and port-forwarding, and of the VM
+// lifecycle service (see VMServiceName).
+//
+// Experimental: the services are not part of the persys-cloud agent API.
+// Until they are defined there as protos they live in persysctl's own
+// ExperimentalPackage, and frames are plain structs carried with a JSON codec
+// (content-subtype "json") over generic gRPC streams, so the wire format may
+// still change. Agents opting in implement:
+//
+//	service WorkloadStreams {
+//	  rpc Logs(LogsRequest) returns (stream LogChunk);
+//	  rpc Exec(stream ExecFrame) returns (stream ExecFrame);
+//	  rpc Attach(stream ExecFrame) returns (stream ExecFrame);
+//	  rpc PortForward(stream PortForwardFrame) returns (stream PortForwardFrame);
+//	}
+//
+// under the full name persysctl.experimental.v1.WorkloadStreams. Agents
+// without it answer with codes.Unimplemented, reported as ErrUnsupported.
+package agentstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// ExperimentalPackage is the proto package of the experimental agent
+// services. It is deliberately outside the upstream persys.agent.v1 package.
+const ExperimentalPackage = "persysctl.experimental.v1"
+
+// ServiceName is the full gRPC service name of the streaming service.
+const ServiceName = ExperimentalPackage + ".WorkloadStreams"
+
+// ErrUnsupported is returned when the agent does not implement a stream.
+var ErrUnsupported = errors.New("compute-agent does not support this operation")
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return "json" }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// LogsRequest selects the log output of one workload. For VM workloads the
+// agent returns the serial console log.
+type LogsRequest struct {
+	WorkloadID string    `json:"workload_id"`
+	Follow     bool      `json:"follow,omitempty"`
+	Since      time.Time `json:"since,omitzero"`
+	// TailLines limits the initial output to the last N lines; <0 means all.
+	TailLines  int64 `json:"tail_lines"`
+	Timestamps bool  `json:"timestamps,omitempty"`
+}
+
+// LogChunk is one piece of log output.
+type LogChunk struct {
+	// Stream is "stdout", "stderr" or "console".
+	Stream    string    `json:"stream,omitempty"`
+	Data      []byte    `json:"data,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+}
+
+// LogStream receives log chunks until io.EOF.
+type LogStream struct {
+	stream grpc.ClientStream
+}
+
+var logsDesc = grpc.StreamDesc{StreamName: "Logs", ServerStreams: true}
+
+// Logs opens a log stream on conn.
+func Logs(ctx context.Context, conn grpc.ClientConnInterface, req LogsRequest) (*LogStream, error) {
+	stream, err := conn.NewStream(ctx, &logsDesc, method(logsDesc), grpc.CallContentSubtype(jsonCodec{}.Name()))
+	if err != nil {
+		return nil, wrap(err)
+	}
+	if err := stream.SendMsg(&req); err != nil {
+		return nil, wrap(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, wrap(err)
+	}
+	return &LogStream{stream: stream}, nil
+}
+
+// Recv returns the next chunk, or io.EOF when the stream ends.
+func (s *LogStream) Recv() (*LogChunk, error) {
+	chunk := &LogChunk{}
+	if err := s.stream.RecvMsg(chunk); err != nil {
+		return nil, wrap(err)
+	}
+	return chunk, nil
+}
+
+func method(desc grpc.StreamDesc) string {
+	return "/" + ServiceName + "/" + desc.StreamName
+}
+
+// wrap maps Unimplemented to ErrUnsupported and passes io.EOF through.
+func wrap(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	if status.Code(err) == codes.Unimplemented {
+		return fmt.Errorf("%w: %s", ErrUnsupported, status.Convert(err).Message())
+	}
+	return err
+}
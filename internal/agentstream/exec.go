@@ -0,0 +1,116 @@
+package agentstream
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Exec and Attach exchange ExecFrames; the first client frame carries
+// Start. Exec runs a new process in the workload; Attach connects to the
+// main process, or to the serial console for VM workloads.
+
+// TerminalSize is a terminal size in character cells.
+type TerminalSize struct {
+	Cols uint32 `json:"cols"`
+	Rows uint32 `json:"rows"`
+}
+
+// ExecStart opens an exec or attach session.
+type ExecStart struct {
+	WorkloadID string        `json:"workload_id"`
+	Command    []string      `json:"command,omitempty"`
+	TTY        bool          `json:"tty,omitempty"`
+	Stdin      bool          `json:"stdin,omitempty"`
+	Size       *TerminalSize `json:"size,omitempty"`
+}
+
+// ExitStatus ends a session.
+type ExitStatus struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// ExecFrame is one message in either direction. Clients send Start, Stdin,
+// CloseStdin and Resize; agents send Stdout, Stderr and finally Exit.
+type ExecFrame struct {
+	Start      *ExecStart    `json:"start,omitempty"`
+	Stdin      []byte        `json:"stdin,omitempty"`
+	CloseStdin bool          `json:"close_stdin,omitempty"`
+	Resize     *TerminalSize `json:"resize,omitempty"`
+	Stdout     []byte        `json:"stdout,omitempty"`
+	Stderr     []byte        `json:"stderr,omitempty"`
+	Exit       *ExitStatus   `json:"exit,omitempty"`
+}
+
+// Session is an open exec or attach stream. Send methods are safe for
+// concurrent use; Recv must be called from a single goroutine.
+type Session struct {
+	stream grpc.ClientStream
+	mu     sync.Mutex
+}
+
+var (
+	execDesc   = grpc.StreamDesc{StreamName: "Exec", ServerStreams: true, ClientStreams: true}
+	attachDesc = grpc.StreamDesc{StreamName: "Attach", ServerStreams: true, ClientStreams: true}
+)
+
+// Exec starts a command in the workload.
+func Exec(ctx context.Context, conn grpc.ClientConnInterface, start ExecStart) (*Session, error) {
+	return openSession(ctx, conn, execDesc, start)
+}
+
+// Attach connects to the workload's main process or VM serial console.
+func Attach(ctx context.Context, conn grpc.ClientConnInterface, start ExecStart) (*Session, error) {
+	return openSession(ctx, conn, attachDesc, start)
+}
+
+func openSession(ctx context.Context, conn grpc.ClientConnInterface, desc grpc.StreamDesc, start ExecStart) (*Session, error) {
+	stream, err := conn.NewStream(ctx, &desc, method(desc), grpc.CallContentSubtype(jsonCodec{}.Name()))
+	if err != nil {
+		return nil, wrap(err)
+	}
+	s := &Session{stream: stream}
+	if err := s.send(&ExecFrame{Start: &start}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SendStdin forwards input to the process.
+func (s *Session) SendStdin(p []byte) error {
+	return s.send(&ExecFrame{Stdin: append([]byte(nil), p...)})
+}
+
+// CloseStdin signals end of input.
+func (s *Session) CloseStdin() error {
+	return s.send(&ExecFrame{CloseStdin: true})
+}
+
+// Resize reports a new terminal size.
+func (s *Session) Resize(size TerminalSize) error {
+	return s.send(&ExecFrame{Resize: &size})
+}
+
+// Recv returns the next agent frame, or io.EOF when the stream ends.
+func (s *Session) Recv() (*ExecFrame, error) {
+	frame := &ExecFrame{}
+	if err := s.stream.RecvMsg(frame); err != nil {
+		return nil, wrap(err)
+	}
+	return frame, nil
+}
+
+// Close half-closes the stream.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return wrap(s.stream.CloseSend())
+}
+
+func (s *Session) send(frame *ExecFrame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return wrap(s.stream.SendMsg(frame))
+}
@@ -0,0 +1,118 @@
+package agentstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// PortForward carries one TCP connection per stream. The first client frame
+// carries Start; afterwards both sides send Data, and Close when their side
+// of the connection has finished writing.
+
+// PortForwardStart opens a tunnel to a port inside the workload.
+type PortForwardStart struct {
+	WorkloadID string `json:"workload_id"`
+	Port       uint32 `json:"port"`
+	Protocol   string `json:"protocol,omitempty"`
+}
+
+// PortForwardFrame is one message in either direction.
+type PortForwardFrame struct {
+	Start *PortForwardStart `json:"start,omitempty"`
+	Data  []byte            `json:"data,omitempty"`
+	Close bool              `json:"close,omitempty"`
+	Error string            `json:"error,omitempty"`
+}
+
+var portForwardDesc = grpc.StreamDesc{StreamName: "PortForward", ServerStreams: true, ClientStreams: true}
+
+// Tunnel is an open port-forward stream.
+type Tunnel struct {
+	stream grpc.ClientStream
+	mu     sync.Mutex
+}
+
+// PortForward opens a tunnel to start.Port in the workload.
+func PortForward(ctx context.Context, conn grpc.ClientConnInterface, start PortForwardStart) (*Tunnel, error) {
+	if start.Protocol == "" {
+		start.Protocol = "tcp"
+	}
+	stream, err := conn.NewStream(ctx, &portForwardDesc, method(portForwardDesc), grpc.CallContentSubtype(jsonCodec{}.Name()))
+	if err != nil {
+		return nil, wrap(err)
+	}
+	t := &Tunnel{stream: stream}
+	if err := t.send(&PortForwardFrame{Start: &start}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Proxy copies data between local and the tunnel until the agent ends the
+// stream, then closes local. On error the stream may still be open; callers
+// should cancel the context the tunnel was opened with once Proxy returns.
+func (t *Tunnel) Proxy(local net.Conn) error {
+	defer local.Close()
+
+	upstream := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := local.Read(buf)
+			if n > 0 {
+				if sendErr := t.send(&PortForwardFrame{Data: append([]byte(nil), buf[:n]...)}); sendErr != nil {
+					upstream <- sendErr
+					return
+				}
+			}
+			if err != nil {
+				_ = t.send(&PortForwardFrame{Close: true})
+				t.mu.Lock()
+				_ = t.stream.CloseSend()
+				t.mu.Unlock()
+				upstream <- nil
+				return
+			}
+		}
+	}()
+
+	for {
+		frame := &PortForwardFrame{}
+		err := t.stream.RecvMsg(frame)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return wrap(err)
+		}
+		if frame.Error != "" {
+			return errors.New(frame.Error)
+		}
+		if len(frame.Data) > 0 {
+			if _, err := local.Write(frame.Data); err != nil {
+				// The local peer went away; that ends the tunnel normally.
+				return nil
+			}
+		}
+		if frame.Close {
+			if cw, ok := local.(interface{ CloseWrite() error }); ok {
+				_ = cw.CloseWrite()
+			}
+		}
+	}
+	// The agent has finished; closing local unblocks the upstream reader.
+	_ = local.Close()
+	<-upstream
+	return nil
+}
+
+func (t *Tunnel) send(frame *PortForwardFrame) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return wrap(t.stream.SendMsg(frame))
+}
@@ -0,0 +1,91 @@
+package agentstream
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// VMServiceName is the full gRPC service name of the VM lifecycle service.
+// Experimental like WorkloadStreams, it lives in ExperimentalPackage and is
+// carried with the JSON codec; agents opting in implement:
+//
+//	service VMLifecycle {
+//	  rpc CreateSnapshot(SnapshotRequest) returns (Snapshot);
+//	  rpc ListSnapshots(ListSnapshotsRequest) returns (ListSnapshotsResponse);
+//	  rpc RevertSnapshot(SnapshotRequest) returns (VMOperationResult);
+//	  rpc Reboot(RebootRequest) returns (VMOperationResult);
+//	}
+const VMServiceName = ExperimentalPackage + ".VMLifecycle"
+
+// SnapshotRequest names a snapshot of a VM workload.
+type SnapshotRequest struct {
+	WorkloadID  string `json:"workload_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// IncludeMemory also saves the running guest's memory state.
+	IncludeMemory bool `json:"include_memory,omitempty"`
+}
+
+// Snapshot is a VM snapshot as reported by the agent.
+type Snapshot struct {
+	WorkloadID  string    `json:"workload_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	State       string    `json:"state,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	SizeBytes   int64     `json:"size_bytes,omitempty"`
+	HasMemory   bool      `json:"has_memory,omitempty"`
+}
+
+// ListSnapshotsRequest selects the snapshots of one VM workload.
+type ListSnapshotsRequest struct {
+	WorkloadID string `json:"workload_id"`
+}
+
+// ListSnapshotsResponse lists snapshots, oldest first.
+type ListSnapshotsResponse struct {
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// RebootRequest reboots a VM workload. Without Force the agent asks the
+// guest to reboot (ACPI); with Force it resets the VM.
+type RebootRequest struct {
+	WorkloadID string `json:"workload_id"`
+	Force      bool   `json:"force,omitempty"`
+}
+
+// VMOperationResult reports the outcome of a VM operation.
+type VMOperationResult struct {
+	Accepted bool   `json:"accepted"`
+	Message  string `json:"message,omitempty"`
+}
+
+// CreateSnapshot snapshots a VM workload.
+func CreateSnapshot(ctx context.Context, conn grpc.ClientConnInterface, req SnapshotRequest) (*Snapshot, error) {
+	out := &Snapshot{}
+	return out, invokeVM(ctx, conn, "CreateSnapshot", &req, out)
+}
+
+// ListSnapshots lists the snapshots of a VM workload.
+func ListSnapshots(ctx context.Context, conn grpc.ClientConnInterface, req ListSnapshotsRequest) (*ListSnapshotsResponse, error) {
+	out := &ListSnapshotsResponse{}
+	return out, invokeVM(ctx, conn, "ListSnapshots", &req, out)
+}
+
+// RevertSnapshot reverts a VM workload to a snapshot.
+func RevertSnapshot(ctx context.Context, conn grpc.ClientConnInterface, req SnapshotRequest) (*VMOperationResult, error) {
+	out := &VMOperationResult{}
+	return out, invokeVM(ctx, conn, "RevertSnapshot", &req, out)
+}
+
+// Reboot reboots a VM workload.
+func Reboot(ctx context.Context, conn grpc.ClientConnInterface, req RebootRequest) (*VMOperationResult, error) {
+	out := &VMOperationResult{}
+	return out, invokeVM(ctx, conn, "Reboot", &req, out)
+}
+
+func invokeVM(ctx context.Context, conn grpc.ClientConnInterface, rpc string, req, resp any) error {
+	return wrap(conn.Invoke(ctx, "/"+VMServiceName+"/"+rpc, req, resp, grpc.CallContentSubtype(jsonCodec{}.Name())))
+}
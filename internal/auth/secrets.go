@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	vaultRefPrefix = "vault:"
+	envRefPrefix   = "env:"
+)
+
+// SecretResolver resolves secret references found in workload specs:
+//
+//	vault:secret/path#key   read key from a Vault KV (v1 or v2) secret
+//	env:NAME                read the local environment variable NAME
+//
+// The Vault client is created lazily with the same token/AppRole settings
+// used for PKI issuance, so specs without vault: references never dial Vault.
+type SecretResolver struct {
+	mgr *Manager
+
+	mu     sync.Mutex
+	client *vault.Client
+	cache  map[string]map[string]interface{}
+}
+
+func NewSecretResolver(cfg Config, logger *logrus.Logger) *SecretResolver {
+	return &SecretResolver{
+		mgr:   NewManager(cfg, logger),
+		cache: map[string]map[string]interface{}{},
+	}
+}
+
+// IsSecretRef reports whether v uses the vault: or env: reference syntax.
+func IsSecretRef(v string) bool {
+	v = strings.TrimSpace(v)
+	return strings.HasPrefix(v, vaultRefPrefix) || strings.HasPrefix(v, envRefPrefix)
+}
+
+// Resolve returns the secret value for ref. Values that are not references
+// are returned unchanged. Errors never include resolved secret material.
+func (r *SecretResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	trimmed := strings.TrimSpace(ref)
+	switch {
+	case strings.HasPrefix(trimmed, envRefPrefix):
+		name := strings.TrimPrefix(trimmed, envRefPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret reference %q: environment variable %s is not set", trimmed, name)
+		}
+		return v, nil
+	case strings.HasPrefix(trimmed, vaultRefPrefix):
+		path, key, err := parseVaultRef(trimmed)
+		if err != nil {
+			return "", err
+		}
+		data, err := r.readKV(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("secret reference %q: %w", trimmed, err)
+		}
+		v, ok := data[key]
+		if !ok {
+			return "", fmt.Errorf("secret reference %q: key %q not found", trimmed, key)
+		}
+		return asString(v), nil
+	default:
+		return ref, nil
+	}
+}
+
+// ResolveMap resolves every reference in m in place.
+func (r *SecretResolver) ResolveMap(ctx context.Context, m map[string]string) error {
+	for k, v := range m {
+		if !IsSecretRef(v) {
+			continue
+		}
+		resolved, err := r.Resolve(ctx, v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		m[k] = resolved
+	}
+	return nil
+}
+
+// parseVaultRef splits a vault:<path>#<key> reference.
+func parseVaultRef(ref string) (path, key string, err error) {
+	path, key, ok := strings.Cut(strings.TrimPrefix(ref, vaultRefPrefix), "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || key == "" {
+		return "", "", fmt.Errorf("secret reference %q: expected vault:<path>#<key>", ref)
+	}
+	return path, key, nil
+}
+
+func (r *SecretResolver) readKV(ctx context.Context, path string) (map[string]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if data, ok := r.cache[path]; ok {
+		return data, nil
+	}
+	if r.client == nil {
+		cli, err := r.mgr.newVaultClient()
+		if err != nil {
+			return nil, fmt.Errorf("vault unavailable: %w", err)
+		}
+		r.client = cli
+	}
+
+	var secret *vault.Secret
+	var err error
+	if mount, v2, ok := r.kvMount(ctx, path); ok {
+		readPath := path
+		if v2 {
+			readPath = kvV2DataPath(mount, path)
+		}
+		secret, err = r.client.Logical().ReadWithContext(ctx, readPath)
+	} else {
+		// Without mount information try the KV v1 layout first. A KV v2
+		// mount answers a v1-style read with no data (and a warning), so
+		// fall back to <mount>/data/<path> in that case too.
+		secret, err = r.client.Logical().ReadWithContext(ctx, path)
+		if err == nil && (secret == nil || secret.Data == nil) {
+			if first, _, found := strings.Cut(path, "/"); found {
+				secret, err = r.client.Logical().ReadWithContext(ctx, kvV2DataPath(first+"/", path))
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret %q not found", path)
+	}
+
+	data := unwrapKV(secret.Data)
+	r.cache[path] = data
+	return data, nil
+}
+
+// kvMount asks Vault which mount serves path and whether it is a KV v2
+// mount, the same preflight the vault CLI performs. ok is false when the
+// endpoint is unavailable, e.g. the token lacks access to it.
+func (r *SecretResolver) kvMount(ctx context.Context, path string) (mount string, v2 bool, ok bool) {
+	secret, err := r.client.Logical().ReadWithContext(ctx, "sys/internal/ui/mounts/"+path)
+	if err != nil || secret == nil || secret.Data == nil {
+		return "", false, false
+	}
+	mount, _ = secret.Data["path"].(string)
+	if mount == "" {
+		return "", false, false
+	}
+	if options, isMap := secret.Data["options"].(map[string]interface{}); isMap {
+		v2 = asString(options["version"]) == "2"
+	}
+	return mount, v2, true
+}
+
+// kvV2DataPath maps a KV path under mount (e.g. "secret/") to the KV v2 data
+// endpoint. Paths that already address <mount>/data/ are kept.
+func kvV2DataPath(mount, path string) string {
+	mount = strings.Trim(mount, "/")
+	rest := strings.TrimPrefix(strings.TrimPrefix(path, mount), "/")
+	if strings.HasPrefix(rest, "data/") {
+		return path
+	}
+	return mount + "/data/" + rest
+}
+
+// unwrapKV returns the secret fields of a KV read: KV v2 responses nest them
+// under "data" next to "metadata".
+func unwrapKV(data map[string]interface{}) map[string]interface{} {
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, isV2 := data["metadata"]; isV2 {
+			return nested
+		}
+	}
+	return data
+}
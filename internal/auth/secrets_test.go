@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/auth"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeVault serves token lookups plus the given path -> JSON responses; any
+// other path is a 404. A nil body answers 403.
+func fakeVault(t *testing.T, routes map[string]*string) auth.Config {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		if path == "auth/token/lookup-self" {
+			_, _ = w.Write([]byte(`{"data":{"id":"test"}}`))
+			return
+		}
+		body, ok := routes[path]
+		switch {
+		case !ok:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		case body == nil:
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(*body))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return auth.Config{VaultAddr: srv.URL, VaultAuthMethod: "token", VaultToken: "test"}
+}
+
+func ptr(s string) *string { return &s }
+
+func resolve(t *testing.T, cfg auth.Config, ref string) (string, error) {
+	t.Helper()
+	return auth.NewSecretResolver(cfg, logrus.New()).Resolve(context.Background(), ref)
+}
+
+func TestResolveKVv2WithMountPreflight(t *testing.T) {
+	cfg := fakeVault(t, map[string]*string{
+		"sys/internal/ui/mounts/secret/app/db": ptr(`{"data":{"path":"secret/","type":"kv","options":{"version":"2"}}}`),
+		"secret/data/app/db":                   ptr(`{"data":{"data":{"password":"s3cret"},"metadata":{"version":1}}}`),
+	})
+	got, err := resolve(t, cfg, "vault:secret/app/db#password")
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Resolve = %q, %v; want s3cret", got, err)
+	}
+}
+
+func TestResolveKVv2WithoutMountAccess(t *testing.T) {
+	// A v1-style read of a KV v2 mount returns no data and a warning.
+	cfg := fakeVault(t, map[string]*string{
+		"sys/internal/ui/mounts/secret/app/db": nil,
+		"secret/app/db":                        ptr(`{"data":null,"warnings":["Invalid path for a versioned K/V secrets engine."]}`),
+		"secret/data/app/db":                   ptr(`{"data":{"data":{"password":"s3cret"},"metadata":{"version":1}}}`),
+	})
+	got, err := resolve(t, cfg, "vault:secret/app/db#password")
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Resolve = %q, %v; want s3cret", got, err)
+	}
+}
+
+func TestResolveKVv1(t *testing.T) {
+	cfg := fakeVault(t, map[string]*string{
+		"sys/internal/ui/mounts/kv/ci": ptr(`{"data":{"path":"kv/","type":"kv","options":null}}`),
+		"kv/ci":                        ptr(`{"data":{"token":"ghp_x","data":"not-nested"}}`),
+	})
+	for ref, want := range map[string]string{"vault:kv/ci#token": "ghp_x", "vault:/kv/ci/#data": "not-nested"} {
+		got, err := resolve(t, cfg, ref)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%s) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+}
+
+func TestResolveMissingKeyOmitsValues(t *testing.T) {
+	cfg := fakeVault(t, map[string]*string{
+		"kv/ci": ptr(`{"data":{"token":"ghp_x"}}`),
+	})
+	_, err := resolve(t, cfg, "vault:kv/ci#password")
+	if err == nil || !strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "ghp_x") {
+		t.Fatalf("expected key-not-found error without secret material, got %v", err)
+	}
+	if _, err := resolve(t, cfg, "vault:kv/other#token"); err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+}
+
+func TestResolveRefSyntax(t *testing.T) {
+	t.Setenv("PERSYS_TEST_SECRET", "from-env")
+	cfg := auth.Config{VaultAddr: "http://127.0.0.1:1", VaultAuthMethod: "token"}
+
+	if got, err := resolve(t, cfg, " env:PERSYS_TEST_SECRET "); err != nil || got != "from-env" {
+		t.Errorf("env ref = %q, %v", got, err)
+	}
+	if _, err := resolve(t, cfg, "env:PERSYS_TEST_UNSET"); err == nil {
+		t.Error("expected error for unset environment variable")
+	}
+	if got, err := resolve(t, cfg, "plain-value"); err != nil || got != "plain-value" {
+		t.Errorf("plain value = %q, %v", got, err)
+	}
+	for _, ref := range []string{"vault:secret/app", "vault:secret/app#", "vault:#key", "vault:/#key"} {
+		_, err := resolve(t, cfg, ref)
+		if err == nil || !strings.Contains(err.Error(), "expected vault:<path>#<key>") {
+			t.Errorf("Resolve(%s) error = %v, want syntax error", ref, err)
+		}
+	}
+	if !auth.IsSecretRef("vault:a#b") || !auth.IsSecretRef("env:X") || auth.IsSecretRef("https://x") {
+		t.Error("IsSecretRef mismatch")
+	}
+}
@@ -0,0 +1,228 @@
+// Package automation evaluates local automation policies against workload
+// listings and turns matches into scheduler automation suggestions.
+//
+// A policy file is YAML (or JSON):
+//
+//	policies:
+//	  - id: retry-network
+//	    name: Retry network failures
+//	    match:
+//	      status: Failed
+//	      failureReasons: [NETWORK_ERROR]
+//	      maxRetryAttempts: 3   # only while attempts < 3
+//	    action:
+//	      type: retry
+//
+// Action types are retry, set-desired-state, delete and scale. The scheduler
+// decides whether to act on a suggestion; policies only propose.
+package automation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// File is a policy file.
+type File struct {
+	Policies []Policy `yaml:"policies" json:"policies"`
+}
+
+// Policy proposes Action for every workload that satisfies Match.
+type Policy struct {
+	ID       string `yaml:"id" json:"id"`
+	Name     string `yaml:"name,omitempty" json:"name,omitempty"`
+	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	Match    Match  `yaml:"match" json:"match"`
+	Action   Action `yaml:"action" json:"action"`
+	Reason   string `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// Match holds the conditions a workload must meet. Empty fields match
+// everything; list fields match when any entry matches.
+type Match struct {
+	WorkloadIDs    []string          `yaml:"workloadIds,omitempty" json:"workloadIds,omitempty"`
+	Types          []string          `yaml:"types,omitempty" json:"types,omitempty"`
+	Status         string            `yaml:"status,omitempty" json:"status,omitempty"`
+	DesiredState   string            `yaml:"desiredState,omitempty" json:"desiredState,omitempty"`
+	NodeID         string            `yaml:"nodeId,omitempty" json:"nodeId,omitempty"`
+	FailureReasons []string          `yaml:"failureReasons,omitempty" json:"failureReasons,omitempty"`
+	Labels         map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// MaxRetryAttempts matches while the workload's retry attempts are
+	// below it. Zero disables the check.
+	MaxRetryAttempts int32 `yaml:"maxRetryAttempts,omitempty" json:"maxRetryAttempts,omitempty"`
+}
+
+// Action is the suggestion a matching policy produces.
+type Action struct {
+	Type            string `yaml:"type" json:"type"`
+	DesiredState    string `yaml:"desiredState,omitempty" json:"desiredState,omitempty"`
+	DesiredReplicas int32  `yaml:"desiredReplicas,omitempty" json:"desiredReplicas,omitempty"`
+	ReplicaDelta    int32  `yaml:"replicaDelta,omitempty" json:"replicaDelta,omitempty"`
+}
+
+// LoadFile reads and validates a policy file.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy file: %w", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("decode policy file %s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("policy file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate checks policy IDs are unique and every action is well formed.
+func (f *File) Validate() error {
+	seen := map[string]bool{}
+	for i, p := range f.Policies {
+		if strings.TrimSpace(p.ID) == "" {
+			return fmt.Errorf("policy %d: id is required", i)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("policy %s: duplicate id", p.ID)
+		}
+		seen[p.ID] = true
+		if _, err := ParseActionType(p.Action.Type); err != nil {
+			return fmt.Errorf("policy %s: %w", p.ID, err)
+		}
+		if err := p.Action.validate(); err != nil {
+			return fmt.Errorf("policy %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// ParseActionType maps a CLI action name, or the enum name itself, to
+// AutomationActionType.
+func ParseActionType(s string) (controlv1.AutomationActionType, error) {
+	normalized := strings.ToLower(strings.NewReplacer("_", "-", " ", "-").Replace(strings.TrimSpace(s)))
+	normalized = strings.TrimPrefix(normalized, "automation-action-")
+	switch normalized {
+	case "retry", "retry-workload":
+		return controlv1.AutomationActionType_AUTOMATION_ACTION_RETRY_WORKLOAD, nil
+	case "set-desired-state", "desired-state":
+		return controlv1.AutomationActionType_AUTOMATION_ACTION_SET_DESIRED_STATE, nil
+	case "delete", "delete-workload":
+		return controlv1.AutomationActionType_AUTOMATION_ACTION_DELETE_WORKLOAD, nil
+	case "scale", "scale-replicas":
+		return controlv1.AutomationActionType_AUTOMATION_ACTION_SCALE_REPLICAS, nil
+	default:
+		return controlv1.AutomationActionType_AUTOMATION_ACTION_TYPE_UNSPECIFIED,
+			fmt.Errorf("invalid action type %q (expected retry|set-desired-state|delete|scale)", s)
+	}
+}
+
+func (a Action) validate() error {
+	actionType, _ := ParseActionType(a.Type)
+	switch actionType {
+	case controlv1.AutomationActionType_AUTOMATION_ACTION_SET_DESIRED_STATE:
+		if strings.TrimSpace(a.DesiredState) == "" {
+			return fmt.Errorf("set-desired-state requires desiredState")
+		}
+	case controlv1.AutomationActionType_AUTOMATION_ACTION_SCALE_REPLICAS:
+		if (a.DesiredReplicas == 0) == (a.ReplicaDelta == 0) {
+			return fmt.Errorf("scale requires exactly one of desiredReplicas or replicaDelta")
+		}
+		if a.DesiredReplicas < 0 {
+			return fmt.Errorf("desiredReplicas must not be negative")
+		}
+	}
+	return nil
+}
+
+// Matches reports whether w satisfies m. labels are the workload's known
+// labels, which listings may not carry themselves.
+func (m Match) Matches(w models.Workload, labels map[string]string) bool {
+	if len(m.WorkloadIDs) > 0 && !containsFold(m.WorkloadIDs, w.ID) {
+		return false
+	}
+	if len(m.Types) > 0 && !containsFold(m.Types, w.Type) {
+		return false
+	}
+	if m.Status != "" && !strings.EqualFold(m.Status, w.Status) {
+		return false
+	}
+	if m.DesiredState != "" && !strings.EqualFold(m.DesiredState, w.DesiredState) {
+		return false
+	}
+	if m.NodeID != "" && m.NodeID != w.NodeID {
+		return false
+	}
+	if len(m.FailureReasons) > 0 {
+		code := ""
+		if w.Reason != nil {
+			code = w.Reason.Code
+		}
+		if !containsFold(m.FailureReasons, w.FailureReason) && (code == "" || !containsFold(m.FailureReasons, code)) {
+			return false
+		}
+	}
+	if m.MaxRetryAttempts > 0 && w.RetryAttempts >= m.MaxRetryAttempts {
+		return false
+	}
+	for k, v := range m.Labels {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Suggestion builds the suggestion a policy makes for workloadID.
+func (p Policy) Suggestion(workloadID string) *controlv1.AutomationSuggestion {
+	actionType, _ := ParseActionType(p.Action.Type)
+	reason := p.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("matched policy %s", p.ID)
+	}
+	return &controlv1.AutomationSuggestion{
+		PolicyId:        p.ID,
+		PolicyName:      p.Name,
+		TargetWorkload:  workloadID,
+		ActionType:      actionType,
+		DesiredState:    p.Action.DesiredState,
+		DesiredReplicas: p.Action.DesiredReplicas,
+		ReplicaDelta:    p.Action.ReplicaDelta,
+		Reason:          reason,
+	}
+}
+
+// Evaluate returns one suggestion per (enabled policy, matching workload)
+// pair, in policy order. labelsFor may be nil.
+func (f *File) Evaluate(workloads []models.Workload, labelsFor func(models.Workload) map[string]string) []*controlv1.AutomationSuggestion {
+	var out []*controlv1.AutomationSuggestion
+	for _, p := range f.Policies {
+		if p.Disabled {
+			continue
+		}
+		for _, w := range workloads {
+			var labels map[string]string
+			if labelsFor != nil && len(p.Match.Labels) > 0 {
+				labels = labelsFor(w)
+			}
+			if p.Match.Matches(w, labels) {
+				out = append(out, p.Suggestion(w.ID))
+			}
+		}
+	}
+	return out
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), v) {
+			return true
+		}
+	}
+	return false
+}
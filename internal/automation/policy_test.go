@@ -0,0 +1,51 @@
+package automation_test
+
+import (
+	"testing"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/automation"
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+func TestEvaluateRetryPolicy(t *testing.T) {
+	f := &automation.File{Policies: []automation.Policy{{
+		ID: "retry-network",
+		Match: automation.Match{
+			Status:           "Failed",
+			FailureReasons:   []string{"NETWORK_ERROR"},
+			MaxRetryAttempts: 3,
+		},
+		Action: automation.Action{Type: "retry"},
+	}}}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	workloads := []models.Workload{
+		{ID: "a", Status: "Failed", FailureReason: "NETWORK_ERROR", RetryAttempts: 1},
+		{ID: "b", Status: "Failed", FailureReason: "NETWORK_ERROR", RetryAttempts: 3},
+		{ID: "c", Status: "Failed", FailureReason: "IMAGE_NOT_FOUND"},
+		{ID: "d", Status: "Running"},
+	}
+	got := f.Evaluate(workloads, nil)
+	if len(got) != 1 || got[0].GetTargetWorkload() != "a" {
+		t.Fatalf("expected a single suggestion for workload a, got %v", got)
+	}
+	if got[0].GetActionType() != controlv1.AutomationActionType_AUTOMATION_ACTION_RETRY_WORKLOAD {
+		t.Fatalf("unexpected action type %v", got[0].GetActionType())
+	}
+}
+
+func TestValidateRejectsBadActions(t *testing.T) {
+	for _, action := range []automation.Action{
+		{Type: "reboot"},
+		{Type: "set-desired-state"},
+		{Type: "scale", DesiredReplicas: 2, ReplicaDelta: 1},
+	} {
+		f := &automation.File{Policies: []automation.Policy{{ID: "p", Action: action}}}
+		if err := f.Validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", action)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+)
+
+// WorkloadActions lists the agent action history of workloadID from the
+// compute-agent hosting it, resolved through the scheduler unless the client
+// already targets an agent.
+func (c *Client) WorkloadActions(workloadID string, limit int32) (*agentv1.ListActionsResponse, error) {
+	conn, closeFn, err := c.WorkloadAgentConn(workloadID)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+	return agentv1.NewAgentServiceClient(conn).ListActions(ctx, &agentv1.ListActionsRequest{
+		WorkloadId:  workloadID,
+		Limit:       limit,
+		NewestFirst: true,
+	})
+}
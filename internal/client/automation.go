@@ -0,0 +1,49 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SubmitAutomationSuggestion proposes an automation action to the scheduler.
+// A suggestion ID and timestamp are filled in when missing; the caller's
+// message is not modified.
+func (c *Client) SubmitAutomationSuggestion(s *controlv1.AutomationSuggestion) (*controlv1.SubmitAutomationSuggestionResponse, error) {
+	if err := c.requireSchedulerGRPC(); err != nil {
+		return nil, err
+	}
+	if s == nil || s.GetTargetWorkload() == "" {
+		return nil, fmt.Errorf("suggestion target workload is required")
+	}
+	if s.GetActionType() == controlv1.AutomationActionType_AUTOMATION_ACTION_TYPE_UNSPECIFIED {
+		return nil, fmt.Errorf("suggestion action type is required")
+	}
+	s = proto.Clone(s).(*controlv1.AutomationSuggestion)
+	if s.GetSuggestionId() == "" {
+		id, err := newSuggestionID()
+		if err != nil {
+			return nil, err
+		}
+		s.SuggestionId = id
+	}
+	if s.GetSuggestedAt() == nil {
+		s.SuggestedAt = timestamppb.Now()
+	}
+
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+	return c.schedulerClient.SubmitAutomationSuggestion(ctx, &controlv1.SubmitAutomationSuggestionRequest{Suggestion: s})
+}
+
+func newSuggestionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate suggestion id: %w", err)
+	}
+	return "cli-" + hex.EncodeToString(b), nil
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/persys-dev/persysctl/internal/auth"
 	"github.com/persys-dev/persysctl/internal/config"
+	"github.com/persys-dev/persysctl/internal/history"
 	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
 	"github.com/persys-dev/persysctl/internal/models"
 	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
@@ -38,6 +39,8 @@ type Client struct {
 	schedulerClient controlv1.AgentControlClient
 	agentClient     agentv1.AgentServiceClient
 	certCancel      context.CancelFunc
+	secrets         *auth.SecretResolver
+	history         *history.Store
 }
 
 type ScheduleResponse struct {
@@ -106,7 +109,7 @@ type ForgeryTestWebhookRequest struct {
 }
 
 func NewClient(cfg config.Config) (*Client, error) {
-	c := &Client{cfg: cfg}
+	c := &Client{cfg: cfg, history: history.NewStore(cfg.HistoryDir)}
 
 	switch cfg.Transport {
 	case "http":
@@ -174,6 +177,16 @@ func newGRPCClient(cfg config.Config) (*grpc.ClientConn, controlv1.AgentControlC
 		return nil, nil, nil, nil, fmt.Errorf("grpc_endpoint is required for grpc transport")
 	}
 
+	conn, certCancel, err := dialGRPC(cfg, cfg.GRPCEndpoint)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return conn, controlv1.NewAgentControlClient(conn), agentv1.NewAgentServiceClient(conn), certCancel, nil
+}
+
+// dialGRPC connects to endpoint with the CLI's mTLS identity (or without TLS
+// when grpc_insecure is set).
+func dialGRPC(cfg config.Config, endpoint string) (*grpc.ClientConn, context.CancelFunc, error) {
 	dialCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RPCTimeoutSeconds)*time.Second)
 	defer cancel()
 
@@ -182,35 +195,45 @@ func newGRPCClient(cfg config.Config) (*grpc.ClientConn, controlv1.AgentControlC
 	if cfg.GRPCInsecure {
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
-		bindHost := hostFromDialTarget(cfg.GRPCEndpoint)
+		bindHost := hostFromDialTarget(endpoint)
 		var err error
 		certCancel, err = ensureVaultManagedCertificates(cfg, bindHost, true)
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return nil, nil, err
 		}
 		tlsConfig, err := buildMTLSConfig(cfg)
 		if err != nil {
 			if certCancel != nil {
 				certCancel()
 			}
-			return nil, nil, nil, nil, err
+			return nil, nil, err
 		}
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 	}
 
-	conn, err := grpc.DialContext(dialCtx, cfg.GRPCEndpoint, dialOpts...)
+	conn, err := grpc.DialContext(dialCtx, endpoint, dialOpts...)
 	if err != nil {
 		if certCancel != nil {
 			certCancel()
 		}
-		return nil, nil, nil, nil, fmt.Errorf("failed to connect to gRPC endpoint %s: %w", cfg.GRPCEndpoint, err)
+		return nil, nil, fmt.Errorf("failed to connect to gRPC endpoint %s: %w", endpoint, err)
 	}
-
-	return conn, controlv1.NewAgentControlClient(conn), agentv1.NewAgentServiceClient(conn), certCancel, nil
+	return conn, certCancel, nil
 }
 
 func ensureVaultManagedCertificates(cfg config.Config, bindHost string, tlsEnabled bool) (context.CancelFunc, error) {
-	certCfg := auth.Config{
+	certMgr := auth.NewManager(vaultAuthConfig(cfg, bindHost, tlsEnabled), newAuthLogger())
+
+	certCtx, cancel := context.WithCancel(context.Background())
+	if err := certMgr.Start(certCtx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to initialize certificate manager: %w", err)
+	}
+	return cancel, nil
+}
+
+func vaultAuthConfig(cfg config.Config, bindHost string, tlsEnabled bool) auth.Config {
+	return auth.Config{
 		TLSEnabled: tlsEnabled,
 
 		TLSCertPath: cfg.CertPath,
@@ -232,18 +255,13 @@ func ensureVaultManagedCertificates(cfg config.Config, bindHost string, tlsEnabl
 
 		BindHost: bindHost,
 	}
+}
 
+func newAuthLogger() *logrus.Logger {
 	logger := logrus.New()
 	logger.SetOutput(os.Stderr)
 	logger.SetFormatter(&logrus.TextFormatter{})
-	certMgr := auth.NewManager(certCfg, logger)
-
-	certCtx, cancel := context.WithCancel(context.Background())
-	if err := certMgr.Start(certCtx); err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to initialize certificate manager: %w", err)
-	}
-	return cancel, nil
+	return logger
 }
 
 func hostFromURL(raw string) string {
@@ -313,6 +331,8 @@ func (c *Client) requireAgentGRPC() error {
 	return nil
 }
 
+// ScheduleWorkload applies a legacy workload definition. Specs accepted by
+// the scheduler are recorded in the revision history.
 func (c *Client) ScheduleWorkload(workload models.Workload) (*ScheduleResponse, error) {
 	switch c.cfg.Transport {
 	case "grpc":
@@ -325,22 +345,21 @@ func (c *Client) ScheduleWorkload(workload models.Workload) (*ScheduleResponse,
 }
 
 func (c *Client) scheduleWorkloadGRPC(workload models.Workload) (*ScheduleResponse, error) {
-	ctx, cancel := c.rpcContext()
-	defer cancel()
-
 	switch c.cfg.GRPCTarget {
 	case "scheduler":
 		req, workloadID, err := toSchedulerApplyRequest(workload)
 		if err != nil {
 			return nil, err
 		}
-		resp, err := c.schedulerClient.ApplyWorkload(ctx, req)
+		resp, err := c.ApplySchedulerWorkload(req)
 		if err != nil {
 			return nil, fmt.Errorf("scheduler apply workload failed: %w", err)
 		}
 		status := "applied"
 		if !resp.GetSuccess() {
 			status = "failed"
+		} else {
+			c.RecordHistory(req)
 		}
 		return &ScheduleResponse{WorkloadID: workloadID, NodeID: "scheduler-managed", Status: status}, nil
 	case "agent":
@@ -348,7 +367,7 @@ func (c *Client) scheduleWorkloadGRPC(workload models.Workload) (*ScheduleRespon
 		if err != nil {
 			return nil, err
 		}
-		resp, err := c.agentClient.ApplyWorkload(ctx, req)
+		resp, err := c.ApplyAgentWorkload(req)
 		if err != nil {
 			return nil, fmt.Errorf("compute-agent apply workload failed: %w", err)
 		}
@@ -489,16 +508,7 @@ func (c *Client) ListNodes(status string) ([]models.Node, error) {
 
 		nodes := make([]models.Node, 0, len(resp.GetNodes()))
 		for _, n := range resp.GetNodes() {
-			nodes = append(nodes, models.Node{
-				NodeID:    n.GetNodeId(),
-				IPAddress: n.GetGrpcEndpoint(),
-				Status:    n.GetStatus(),
-				Resources: models.Resources{
-					CPU:    int(math.Round(n.GetTotalCpuCores() * 1000)),
-					Memory: int(n.GetTotalMemoryMb()),
-				},
-				Labels: n.GetLabels(),
-			})
+			nodes = append(nodes, toModelNode(n))
 		}
 		return nodes, nil
 	default:
@@ -518,6 +528,19 @@ func (c *Client) GetNode(nodeID string) (*controlv1.GetNodeResponse, error) {
 	return c.schedulerClient.GetNode(ctx, &controlv1.GetNodeRequest{NodeId: nodeID})
 }
 
+// NodeInfo returns a single node in the models.Node form used by ListNodes.
+func (c *Client) NodeInfo(nodeID string) (*models.Node, error) {
+	resp, err := c.GetNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetNode() == nil {
+		return nil, fmt.Errorf("node %s not found", nodeID)
+	}
+	node := toModelNode(resp.GetNode())
+	return &node, nil
+}
+
 func (c *Client) SchedulerListNodes(status string) (*controlv1.ListNodesResponse, error) {
 	if c.cfg.Transport == "http" {
 		return c.schedulerListNodesHTTP(status)
@@ -600,26 +623,62 @@ func (c *Client) ControlStreamSend(msg *controlv1.ControlMessage) (*controlv1.Co
 	return resp, nil
 }
 
+// WatchControlStream keeps a ControlStream open and calls fn for every
+// message the scheduler sends until ctx is cancelled, the stream ends or fn
+// returns an error. A stream closed by the scheduler returns io.EOF so
+// callers can tell it apart from cancellation.
+func (c *Client) WatchControlStream(ctx context.Context, fn func(*controlv1.ControlMessage) error) error {
+	if err := c.requireSchedulerGRPC(); err != nil {
+		return err
+	}
+	stream, err := c.schedulerClient.ControlStream(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// ApplySchedulerWorkload applies req through the scheduler. It does not
+// record req in the revision history; see RecordHistory.
 func (c *Client) ApplySchedulerWorkload(req *controlv1.ApplyWorkloadRequest) (*controlv1.ApplyWorkloadResponse, error) {
+	req, err := c.resolveSchedulerRequestSecrets(req)
+	if err != nil {
+		return nil, err
+	}
 	if c.cfg.Transport == "http" {
 		resp := &controlv1.ApplyWorkloadResponse{}
 		if err := c.httpProtoRequest("POST", "/workloads/schedule", req, resp); err != nil {
-			return nil, err
+			return nil, redactError(err)
 		}
-		return resp, nil
+		return redactApplyResult(resp, nil)
 	}
 	if err := c.requireSchedulerGRPC(); err != nil {
 		return nil, err
 	}
 	ctx, cancel := c.rpcContext()
 	defer cancel()
-	return c.schedulerClient.ApplyWorkload(ctx, req)
+	return redactApplyResult(c.schedulerClient.ApplyWorkload(ctx, req))
 }
 
 func (c *Client) ApplyAgentWorkload(req *agentv1.ApplyWorkloadRequest) (*agentv1.ApplyWorkloadResponse, error) {
 	if err := c.requireAgentGRPC(); err != nil {
 		return nil, err
 	}
+	req, err := c.resolveAgentRequestSecrets(req)
+	if err != nil {
+		return nil, err
+	}
 	ctx, cancel := c.rpcContext()
 	defer cancel()
 	return c.agentClient.ApplyWorkload(ctx, req)
@@ -667,23 +726,28 @@ func (c *Client) AgentListActions(workloadID, actionType, status string, limit i
 	})
 }
 
-func (c *Client) GetMetrics() (map[string]interface{}, error) {
+// GetMetrics returns the cluster summary in the same shape for both
+// transports.
+func (c *Client) GetMetrics() (*models.ClusterMetrics, error) {
 	if c.cfg.Transport == "grpc" && c.cfg.GRPCTarget == "scheduler" {
 		summary, err := c.GetClusterSummary()
 		if err != nil {
 			return nil, err
 		}
-		return map[string]interface{}{
-			"totalNodes":       summary.GetTotalNodes(),
-			"readyNodes":       summary.GetReadyNodes(),
-			"notReadyNodes":    summary.GetNotReadyNodes(),
-			"totalWorkloads":   summary.GetTotalWorkloads(),
-			"runningWorkloads": summary.GetRunningWorkloads(),
-			"pendingWorkloads": summary.GetPendingWorkloads(),
-			"failedWorkloads":  summary.GetFailedWorkloads(),
-			"deletedWorkloads": summary.GetDeletedWorkloads(),
-			"generatedAt":      summary.GetGeneratedAt().AsTime().Format(time.RFC3339),
-		}, nil
+		metrics := &models.ClusterMetrics{
+			TotalNodes:       int(summary.GetTotalNodes()),
+			ReadyNodes:       int(summary.GetReadyNodes()),
+			NotReadyNodes:    int(summary.GetNotReadyNodes()),
+			TotalWorkloads:   int(summary.GetTotalWorkloads()),
+			RunningWorkloads: int(summary.GetRunningWorkloads()),
+			PendingWorkloads: int(summary.GetPendingWorkloads()),
+			FailedWorkloads:  int(summary.GetFailedWorkloads()),
+			DeletedWorkloads: int(summary.GetDeletedWorkloads()),
+		}
+		if summary.GetGeneratedAt() != nil {
+			metrics.GeneratedAt = summary.GetGeneratedAt().AsTime()
+		}
+		return metrics, nil
 	}
 	if c.cfg.Transport != "http" {
 		return nil, fmt.Errorf("metrics are available only with scheduler gRPC or http transport")
@@ -709,25 +773,72 @@ func (c *Client) GetMetrics() (map[string]interface{}, error) {
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var metrics map[string]interface{}
-	if err := json.Unmarshal(body, &metrics); err != nil {
+	metrics, err := decodeClusterMetrics(body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode response: %v", err)
 	}
 	return metrics, nil
 }
 
+// decodeClusterMetrics maps the gateway's /cluster/metrics body onto
+// ClusterMetrics. Summary fields are matched in camelCase or snake_case;
+// anything else is kept as an extension.
+func decodeClusterMetrics(body []byte) (*models.ClusterMetrics, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	metrics := &models.ClusterMetrics{}
+	counts := map[string]*int{
+		"totalnodes":       &metrics.TotalNodes,
+		"readynodes":       &metrics.ReadyNodes,
+		"notreadynodes":    &metrics.NotReadyNodes,
+		"totalworkloads":   &metrics.TotalWorkloads,
+		"runningworkloads": &metrics.RunningWorkloads,
+		"pendingworkloads": &metrics.PendingWorkloads,
+		"failedworkloads":  &metrics.FailedWorkloads,
+		"deletedworkloads": &metrics.DeletedWorkloads,
+	}
+	for key, value := range raw {
+		normalized := strings.ToLower(strings.ReplaceAll(key, "_", ""))
+		if dst, ok := counts[normalized]; ok {
+			var n float64
+			if err := json.Unmarshal(value, &n); err == nil {
+				*dst = int(n)
+				continue
+			}
+		}
+		if normalized == "generatedat" {
+			var ts string
+			if err := json.Unmarshal(value, &ts); err == nil {
+				if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+					metrics.GeneratedAt = t
+					continue
+				}
+			}
+		}
+		if metrics.Extensions == nil {
+			metrics.Extensions = map[string]json.RawMessage{}
+		}
+		metrics.Extensions[key] = value
+	}
+	return metrics, nil
+}
+
 func (c *Client) scheduleWorkloadHTTP(workload models.Workload) (*ScheduleResponse, error) {
 	req, workloadID, err := toSchedulerApplyRequest(workload)
 	if err != nil {
 		return nil, err
 	}
-	resp := &controlv1.ApplyWorkloadResponse{}
-	if err := c.httpProtoRequest("POST", "/workloads/schedule", req, resp); err != nil {
+	resp, err := c.ApplySchedulerWorkload(req)
+	if err != nil {
 		return nil, err
 	}
 	scheduleResp := ScheduleResponse{WorkloadID: workloadID, NodeID: "scheduler-managed", Status: "applied"}
 	if !resp.GetSuccess() {
 		scheduleResp.Status = "failed"
+	} else {
+		c.RecordHistory(req)
 	}
 	return &scheduleResp, nil
 }
@@ -790,27 +901,25 @@ func (c *Client) listNodesHTTP(status string) ([]models.Node, error) {
 
 	nodes := make([]models.Node, 0, len(protoResp.GetNodes()))
 	for _, n := range protoResp.GetNodes() {
-		nodes = append(nodes, models.Node{
-			NodeID:    n.GetNodeId(),
-			IPAddress: n.GetGrpcEndpoint(),
-			Status:    n.GetStatus(),
-			Resources: models.Resources{
-				CPU:    int(math.Round(n.GetTotalCpuCores() * 1000)),
-				Memory: int(n.GetTotalMemoryMb()),
-			},
-			Labels: n.GetLabels(),
-		})
+		nodes = append(nodes, toModelNode(n))
 	}
 	return nodes, nil
 }
 
 func (c *Client) makeRequest(method, path string, body io.Reader) (*http.Response, error) {
+	return c.makeRequestWithHeaders(method, path, body, nil)
+}
+
+func (c *Client) makeRequestWithHeaders(method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
 	url := c.cfg.APIEndpoint + path
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %v", err)
@@ -876,34 +985,39 @@ func (c *Client) SendForgeryTestWebhook(req ForgeryTestWebhookRequest) (map[stri
 }
 
 func (c *Client) httpProtoRequest(method, path string, reqMsg proto.Message, respMsg proto.Message) error {
+	_, err := c.httpProtoRequestWithHeaders(method, path, nil, reqMsg, respMsg)
+	return err
+}
+
+func (c *Client) httpProtoRequestWithHeaders(method, path string, headers map[string]string, reqMsg proto.Message, respMsg proto.Message) (http.Header, error) {
 	var body io.Reader
 	if reqMsg != nil {
 		payload, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(reqMsg)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request: %w", err)
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
 		body = bytes.NewBuffer(payload)
 	}
-	resp, err := c.makeRequest(method, path, body)
+	resp, err := c.makeRequestWithHeaders(method, path, body, headers)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to read response body: %v", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
 	}
 	if respMsg == nil {
-		return nil
+		return resp.Header, nil
 	}
 	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(respBody, respMsg); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-	return nil
+	return resp.Header, nil
 }
 
 func (c *Client) httpJSONRequest(method, path string, reqBody interface{}, respBody interface{}) error {
@@ -1018,13 +1132,37 @@ func workloadID(w models.Workload) string {
 	return fmt.Sprintf("workload-%d", time.Now().Unix())
 }
 
+func toModelNode(n *controlv1.NodeView) models.Node {
+	lastHeartbeat := time.Time{}
+	if n.GetLastHeartbeat() != nil {
+		lastHeartbeat = n.GetLastHeartbeat().AsTime()
+	}
+	return models.Node{
+		NodeID:       n.GetNodeId(),
+		IPAddress:    n.GetGrpcEndpoint(),
+		Status:       n.GetStatus(),
+		StatusReason: n.GetStatusReason(),
+		Resources: models.Resources{
+			CPU:    int(math.Round(n.GetTotalCpuCores() * 1000)),
+			Memory: int(n.GetTotalMemoryMb()),
+		},
+		Available: models.Resources{
+			CPU:    int(math.Round(n.GetAvailableCpuCores() * 1000)),
+			Memory: int(n.GetAvailableMemoryMb()),
+		},
+		SupportedWorkloadTypes: n.GetSupportedWorkloadTypes(),
+		LastHeartbeat:          lastHeartbeat,
+		Labels:                 n.GetLabels(),
+	}
+}
+
 func toModelReason(in *controlv1.ReasonDetail) *models.WorkloadReason {
 	if in == nil {
 		return nil
 	}
 	out := &models.WorkloadReason{
 		Code:      strings.TrimSpace(in.GetCode()),
-		Message:   strings.TrimSpace(in.GetMessage()),
+		Message:   RedactURLCredentials(strings.TrimSpace(in.GetMessage())),
 		Retryable: in.GetRetryable(),
 	}
 	if ts := in.GetLastTransition(); ts != nil {
@@ -1072,14 +1210,9 @@ func toSchedulerApplyRequest(w models.Workload) (*controlv1.ApplyWorkloadRequest
 		return nil, "", err
 	}
 
-	revision := fmt.Sprintf("rev-%d", time.Now().Unix())
-	if w.ID != "" {
-		revision = w.ID + "-rev"
-	}
-
 	return &controlv1.ApplyWorkloadRequest{
 		WorkloadId:   id,
-		RevisionId:   revision,
+		RevisionId:   ContentRevision(spec),
 		DesiredState: "Running",
 		Spec:         spec,
 	}, id, nil
@@ -1113,8 +1246,15 @@ func toSchedulerWorkloadSpec(w models.Workload) (*controlv1.WorkloadSpec, error)
 		spec.Type = "compose"
 		compose := &controlv1.ComposeSpec{Env: w.EnvVars}
 		if w.Type == "git-compose" && w.GitRepo != "" {
+			if token := strings.TrimSpace(w.GitToken); token != "" && !auth.IsSecretRef(token) {
+				return nil, fmt.Errorf("--git-token must be a secret reference (vault:<path>#<key> or env:<NAME>); the scheduler stores the repository URL the token is sent in")
+			}
+			repo, err := gitRepoWithToken(w.GitRepo, w.GitToken)
+			if err != nil {
+				return nil, err
+			}
 			compose.SourceType = "git"
-			compose.GitRepo = w.GitRepo
+			compose.GitRepo = repo
 			compose.GitRef = w.GitBranch
 		} else {
 			compose.SourceType = "inline"
@@ -1132,7 +1272,6 @@ func toAgentApplyRequest(w models.Workload) (*agentv1.ApplyWorkloadRequest, stri
 	id := workloadID(w)
 	req := &agentv1.ApplyWorkloadRequest{
 		Id:           id,
-		RevisionId:   fmt.Sprintf("rev-%d", time.Now().Unix()),
 		DesiredState: agentv1.DesiredState_DESIRED_STATE_RUNNING,
 		Spec:         &agentv1.WorkloadSpec{},
 	}
@@ -1164,6 +1303,7 @@ func toAgentApplyRequest(w models.Workload) (*agentv1.ApplyWorkloadRequest, stri
 		return nil, "", fmt.Errorf("unsupported workload type for compute-agent grpc: %s", w.Type)
 	}
 
+	req.RevisionId = ContentRevision(req.Spec)
 	return req, id, nil
 }
 
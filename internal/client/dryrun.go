@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/config"
+	"github.com/persys-dev/persysctl/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// dryRunHeader marks a request as a server-side preview. It is sent as
+	// gRPC metadata or as an HTTP header to the gateway.
+	dryRunHeader = "x-persys-dry-run"
+	// dryRunSupportedHeader is returned by schedulers (and gateways) that
+	// honour dryRunHeader. Without it the preview request is never sent,
+	// because an unaware scheduler would apply the workload for real.
+	dryRunSupportedHeader = "x-persys-dry-run-supported"
+)
+
+// BuildApplyRequest returns the request ScheduleWorkload would send for cfg,
+// without dialing. Secret references are left unresolved.
+func BuildApplyRequest(cfg config.Config, w models.Workload) (proto.Message, error) {
+	if cfg.Transport == "grpc" && cfg.GRPCTarget == "agent" {
+		req, _, err := toAgentApplyRequest(w)
+		return req, err
+	}
+	req, _, err := toSchedulerApplyRequest(w)
+	return req, err
+}
+
+// PreviewSchedulerWorkload sends req with the server-side dry-run marker.
+// It fails without sending anything when the scheduler does not advertise
+// dry-run support.
+func (c *Client) PreviewSchedulerWorkload(req *controlv1.ApplyWorkloadRequest) (*controlv1.ApplyWorkloadResponse, error) {
+	supported, err := c.schedulerSupportsDryRun()
+	if err != nil {
+		return nil, fmt.Errorf("probe server-side dry-run support: %w", err)
+	}
+	if !supported {
+		return nil, fmt.Errorf("scheduler does not advertise server-side dry-run support; use --dry-run=client")
+	}
+
+	req, err = c.resolveSchedulerRequestSecrets(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.Transport == "http" {
+		resp := &controlv1.ApplyWorkloadResponse{}
+		if _, err := c.httpProtoRequestWithHeaders("POST", "/workloads/schedule", map[string]string{dryRunHeader: "server"}, req, resp); err != nil {
+			return nil, redactError(err)
+		}
+		return redactApplyResult(resp, nil)
+	}
+	if err := c.requireSchedulerGRPC(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, dryRunHeader, "server")
+	return redactApplyResult(c.schedulerClient.ApplyWorkload(ctx, req))
+}
+
+func (c *Client) schedulerSupportsDryRun() (bool, error) {
+	if c.cfg.Transport == "http" {
+		header, err := c.httpProtoRequestWithHeaders("GET", "/cluster/metrics", map[string]string{dryRunHeader: "probe"}, nil, nil)
+		if err != nil {
+			return false, err
+		}
+		return strings.EqualFold(header.Get(dryRunSupportedHeader), "true"), nil
+	}
+	if err := c.requireSchedulerGRPC(); err != nil {
+		return false, err
+	}
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, dryRunHeader, "probe")
+
+	var header metadata.MD
+	if _, err := c.schedulerClient.GetClusterSummary(ctx, &controlv1.GetClusterSummaryRequest{}, grpc.Header(&header)); err != nil {
+		return false, err
+	}
+	for _, v := range header.Get(dryRunSupportedHeader) {
+		if strings.EqualFold(v, "true") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// AgentProbe is the result of one agent health check.
+type AgentProbe struct {
+	Endpoint string
+	Latency  time.Duration
+	Health   *agentv1.HealthCheckResponse
+	Err      error
+}
+
+// errNoEndpoint is the probe error for an empty endpoint, which is never
+// dialed.
+var errNoEndpoint = errors.New("no endpoint")
+
+// ProbeAgents calls HealthCheck on every endpoint with at most parallel
+// checks in flight. Results are in endpoint order. Certificates are set up
+// once and shared by all dials.
+func (c *Client) ProbeAgents(endpoints []string, timeout time.Duration, parallel int) ([]AgentProbe, error) {
+	creds := insecure.NewCredentials()
+	if !c.cfg.GRPCInsecure {
+		certCancel, err := ensureVaultManagedCertificates(c.cfg, "", true)
+		if err != nil {
+			return nil, err
+		}
+		if certCancel != nil {
+			defer certCancel()
+		}
+		tlsConfig, err := buildMTLSConfig(c.cfg)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]AgentProbe, len(endpoints))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		if endpoint == "" {
+			results[i] = AgentProbe{Err: errNoEndpoint}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = probeAgent(endpoint, creds, timeout)
+		}(i, endpoint)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func probeAgent(endpoint string, creds credentials.TransportCredentials, timeout time.Duration) AgentProbe {
+	out := AgentProbe{Endpoint: endpoint}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, endpoint, grpc.WithBlock(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		out.Err = err
+		return out
+	}
+	defer conn.Close()
+	start := time.Now()
+	out.Health, out.Err = agentv1.NewAgentServiceClient(conn).HealthCheck(ctx, &agentv1.HealthCheckRequest{})
+	out.Latency = time.Since(start)
+	return out
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/history"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentRevision derives the revision ID for a spec from its deterministic
+// wire encoding, so re-applying an unchanged spec keeps its revision. Specs
+// are hashed before secret resolution.
+func ContentRevision(spec proto.Message) string {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(spec)
+	if err != nil {
+		return fmt.Sprintf("rev-%d", time.Now().Unix())
+	}
+	return history.RevisionID(b)
+}
+
+// History returns the local revision history store.
+func (c *Client) History() *history.Store {
+	return c.history
+}
+
+// RecordHistory stores an accepted scheduler apply. Commands that apply a
+// user-supplied spec, or a fanned-out replica of one, call it after the
+// scheduler accepted req; generated applies are not recorded. Failures only
+// warn: the workload has already been applied.
+func (c *Client) RecordHistory(req *controlv1.ApplyWorkloadRequest) {
+	if req.GetSpec() == nil || req.GetWorkloadId() == "" {
+		return
+	}
+	// Never store a plaintext URL password; secret references are kept.
+	if compose := req.GetSpec().GetCompose(); compose != nil {
+		if repo := RedactURLCredentials(compose.GetGitRepo()); repo != compose.GetGitRepo() {
+			req = proto.Clone(req).(*controlv1.ApplyWorkloadRequest)
+			req.GetSpec().GetCompose().GitRepo = repo
+		}
+	}
+	spec, err := protojson.Marshal(req.GetSpec())
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: record revision history: %v\n", err)
+		return
+	}
+	revision := req.GetRevisionId()
+	if revision == "" {
+		revision = ContentRevision(req.GetSpec())
+	}
+	err = c.History().Record(history.Entry{
+		WorkloadID:   req.GetWorkloadId(),
+		RevisionID:   revision,
+		Type:         req.GetSpec().GetType(),
+		DesiredState: req.GetDesiredState(),
+		Transport:    c.cfg.Transport,
+		Spec:         spec,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "warning: record revision history: %v\n", err)
+	}
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	agentv1 "github.com/persys-dev/persys-cloud/pkg/agent/api/v1"
+	controlv1 "github.com/persys-dev/persys-cloud/pkg/scheduler/controlv1"
+	"github.com/persys-dev/persysctl/internal/auth"
+	"github.com/persys-dev/persysctl/internal/config"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Secret references (vault:path#key, env:NAME) are resolved on copies of the
+// outgoing request, so callers that log or print their request only ever
+// see the references.
+
+func (c *Client) secretResolver() *auth.SecretResolver {
+	if c.secrets == nil {
+		c.secrets = auth.NewSecretResolver(vaultAuthConfig(c.cfg, "", false), newAuthLogger())
+	}
+	return c.secrets
+}
+
+func (c *Client) resolveSecretMap(ctx context.Context, scope string, m map[string]string) (map[string]string, error) {
+	if !hasSecretRef(m) {
+		return m, nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	if err := c.secretResolver().ResolveMap(ctx, out); err != nil {
+		return nil, fmt.Errorf("resolve %s secrets: %w", scope, err)
+	}
+	config.Logf("resolved secret references in %s", scope)
+	return out, nil
+}
+
+func (c *Client) resolveSchedulerRequestSecrets(req *controlv1.ApplyWorkloadRequest) (*controlv1.ApplyWorkloadRequest, error) {
+	container := req.GetSpec().GetContainer()
+	compose := req.GetSpec().GetCompose()
+	if !hasSecretRef(container.GetEnv()) && !hasSecretRef(compose.GetEnv()) && gitTokenRef(compose.GetGitRepo()) == "" {
+		return req, nil
+	}
+
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+
+	out := proto.Clone(req).(*controlv1.ApplyWorkloadRequest)
+	if container := out.GetSpec().GetContainer(); container != nil {
+		env, err := c.resolveSecretMap(ctx, "container env", container.GetEnv())
+		if err != nil {
+			return nil, err
+		}
+		container.Env = env
+	}
+	if compose := out.GetSpec().GetCompose(); compose != nil {
+		env, err := c.resolveSecretMap(ctx, "compose env", compose.GetEnv())
+		if err != nil {
+			return nil, err
+		}
+		compose.Env = env
+		if ref := gitTokenRef(compose.GetGitRepo()); ref != "" {
+			token, err := c.secretResolver().Resolve(ctx, ref)
+			if err != nil {
+				return nil, fmt.Errorf("resolve git token secret: %w", err)
+			}
+			repo, err := gitRepoWithToken(compose.GetGitRepo(), token)
+			if err != nil {
+				return nil, err
+			}
+			compose.GitRepo = repo
+			config.Logf("resolved secret reference in git token")
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) resolveAgentRequestSecrets(req *agentv1.ApplyWorkloadRequest) (*agentv1.ApplyWorkloadRequest, error) {
+	container := req.GetSpec().GetContainer()
+	compose := req.GetSpec().GetCompose()
+	if !hasSecretRef(container.GetEnv()) && !hasSecretRef(compose.GetEnv()) {
+		return req, nil
+	}
+
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+
+	out := proto.Clone(req).(*agentv1.ApplyWorkloadRequest)
+	if container := out.GetSpec().GetContainer(); container != nil {
+		env, err := c.resolveSecretMap(ctx, "container env", container.GetEnv())
+		if err != nil {
+			return nil, err
+		}
+		container.Env = env
+	}
+	if compose := out.GetSpec().GetCompose(); compose != nil {
+		env, err := c.resolveSecretMap(ctx, "compose env", compose.GetEnv())
+		if err != nil {
+			return nil, err
+		}
+		compose.Env = env
+	}
+	return out, nil
+}
+
+func hasSecretRef(m map[string]string) bool {
+	for _, v := range m {
+		if auth.IsSecretRef(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitTokenUser is the username sent with a Git token when the repository URL
+// does not name one. GitHub, GitLab and Gitea accept a token with any user.
+const gitTokenUser = "x-access-token"
+
+// gitRepoWithToken carries a Git token, or a secret reference to one, as the
+// password of an http(s) repository URL; ComposeSpec has no other place for
+// Git credentials. The scheduler stores the URL it receives, so persysctl
+// only accepts a secret reference as --git-token and redacts URL passwords
+// wherever it records or prints them (see RedactURLCredentials).
+func gitRepoWithToken(repo, token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return repo, nil
+	}
+	u, err := url.Parse(repo)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("a git token requires an http(s) repository URL")
+	}
+	user := gitTokenUser
+	if u.User != nil && u.User.Username() != "" {
+		user = u.User.Username()
+	}
+	u.User = url.UserPassword(user, token)
+	return u.String(), nil
+}
+
+// gitTokenRef returns the secret reference carried as the password of repo,
+// if any.
+func gitTokenRef(repo string) string {
+	if repo == "" {
+		return ""
+	}
+	u, err := url.Parse(repo)
+	if err != nil || u.User == nil {
+		return ""
+	}
+	if password, ok := u.User.Password(); ok && auth.IsSecretRef(password) {
+		return password
+	}
+	return ""
+}
+
+// urlUserinfoPattern matches the "user:password@" part of URLs in free text.
+var urlUserinfoPattern = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^\s/:@]*):([^\s/@]*)@`)
+
+// RedactURLCredentials replaces URL passwords in s with "***". Secret
+// references are kept, since they are not secrets themselves.
+func RedactURLCredentials(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+	return urlUserinfoPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := urlUserinfoPattern.FindStringSubmatch(m)
+		if password, err := url.PathUnescape(parts[2]); err == nil && auth.IsSecretRef(password) {
+			return m
+		}
+		return parts[1] + ":***@"
+	})
+}
+
+// redactError applies RedactURLCredentials to err, keeping its gRPC status
+// code.
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	redacted := RedactURLCredentials(msg)
+	if redacted == msg {
+		return err
+	}
+	if st, ok := status.FromError(err); ok {
+		return status.Error(st.Code(), RedactURLCredentials(st.Message()))
+	}
+	return errors.New(redacted)
+}
+
+// redactApplyResult scrubs credentials a scheduler may echo back, e.g. in a
+// failed git clone.
+func redactApplyResult(resp *controlv1.ApplyWorkloadResponse, err error) (*controlv1.ApplyWorkloadResponse, error) {
+	if resp != nil && resp.ErrorMessage != "" {
+		resp.ErrorMessage = RedactURLCredentials(resp.ErrorMessage)
+	}
+	return resp, redactError(err)
+}
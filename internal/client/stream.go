@@ -0,0 +1,267 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/agentstream"
+	"google.golang.org/grpc"
+)
+
+// WorkloadAgentEndpoint resolves the compute-agent hosting workloadID from
+// the scheduler's assignment (WorkloadView.AssignedNodeId) and the node's
+// advertised NodeView.GrpcEndpoint.
+func (c *Client) WorkloadAgentEndpoint(workloadID string) (nodeID, endpoint string, err error) {
+	wresp, err := c.GetWorkload(workloadID)
+	if err != nil {
+		return "", "", fmt.Errorf("get workload %s: %w", workloadID, err)
+	}
+	nodeID = strings.TrimSpace(wresp.GetWorkload().GetAssignedNodeId())
+	if nodeID == "" {
+		return "", "", fmt.Errorf("workload %s is not assigned to a node", workloadID)
+	}
+	endpoint, err = c.NodeAgentEndpoint(nodeID)
+	return nodeID, endpoint, err
+}
+
+// NodeAgentEndpoint returns the compute-agent gRPC endpoint of nodeID.
+func (c *Client) NodeAgentEndpoint(nodeID string) (string, error) {
+	nresp, err := c.GetNode(nodeID)
+	if err != nil {
+		return "", fmt.Errorf("get node %s: %w", nodeID, err)
+	}
+	endpoint := strings.TrimSpace(nresp.GetNode().GetGrpcEndpoint())
+	if endpoint == "" {
+		return "", fmt.Errorf("node %s has no gRPC endpoint", nodeID)
+	}
+	return endpoint, nil
+}
+
+// DialAgent connects to a compute-agent endpoint with the same mTLS identity
+// as the main connection. The returned func closes the connection.
+func (c *Client) DialAgent(endpoint string) (*grpc.ClientConn, func(), error) {
+	conn, certCancel, err := dialGRPC(c.cfg, endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, func() {
+		_ = conn.Close()
+		if certCancel != nil {
+			certCancel()
+		}
+	}, nil
+}
+
+// WorkloadAgentConn returns a connection to the compute-agent running
+// workloadID: the current connection for --grpc-target agent, otherwise the
+// agent resolved through the scheduler.
+func (c *Client) WorkloadAgentConn(workloadID string) (grpc.ClientConnInterface, func(), error) {
+	if c.cfg.Transport == "grpc" && c.cfg.GRPCTarget == "agent" {
+		return c.grpcConn, func() {}, nil
+	}
+	nodeID, endpoint, err := c.WorkloadAgentEndpoint(workloadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, closeFn, err := c.DialAgent(endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to agent on node %s: %w", nodeID, err)
+	}
+	return conn, closeFn, nil
+}
+
+// StreamWorkloadLogs calls fn for each log chunk until the stream ends or
+// ctx is cancelled. Over http the gateway log endpoint is used (SSE or
+// chunked text); over grpc the agent's Logs stream.
+func (c *Client) StreamWorkloadLogs(ctx context.Context, req agentstream.LogsRequest, fn func(*agentstream.LogChunk) error) error {
+	if c.cfg.Transport == "http" {
+		return c.streamGatewayLogs(ctx, req, fn)
+	}
+
+	conn, closeFn, err := c.WorkloadAgentConn(req.WorkloadID)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	stream, err := agentstream.Logs(ctx, conn, req)
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) streamGatewayLogs(ctx context.Context, req agentstream.LogsRequest, fn func(*agentstream.LogChunk) error) error {
+	q := make(url.Values)
+	if req.Follow {
+		q.Set("follow", "true")
+	}
+	if !req.Since.IsZero() {
+		q.Set("since", req.Since.UTC().Format(time.RFC3339))
+	}
+	if req.TailLines >= 0 {
+		q.Set("tail", strconv.FormatInt(req.TailLines, 10))
+	}
+	if req.Timestamps {
+		q.Set("timestamps", "true")
+	}
+	path := "/workloads/" + url.PathEscape(req.WorkloadID) + "/logs"
+	if encoded := q.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.cfg.APIEndpoint+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Accept", "text/event-stream, text/plain")
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+			return fmt.Errorf("%w: gateway returned status %d: %s", agentstream.ErrUnsupported, resp.StatusCode, string(body))
+		}
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	sse := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	err = readGatewayLogs(resp.Body, sse, fn)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// readGatewayLogs decodes an SSE stream, whose data fields carry JSON log
+// chunks or raw lines, or a chunked plain-text body.
+func readGatewayLogs(r io.Reader, sse bool, fn func(*agentstream.LogChunk) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !sse {
+		for scanner.Scan() {
+			if err := fn(&agentstream.LogChunk{Stream: "stdout", Data: []byte(scanner.Text() + "\n")}); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	}
+
+	var data []string
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		payload := strings.Join(data, "\n")
+		data = data[:0]
+		return fn(decodeGatewayLogEvent(payload))
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func decodeGatewayLogEvent(payload string) *agentstream.LogChunk {
+	var event struct {
+		Stream    string    `json:"stream"`
+		Data      []byte    `json:"data"`
+		Line      string    `json:"line"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(payload), &event); err != nil || (event.Data == nil && event.Line == "") {
+		return &agentstream.LogChunk{Stream: "stdout", Data: []byte(payload + "\n")}
+	}
+	chunk := &agentstream.LogChunk{Stream: event.Stream, Data: event.Data, Timestamp: event.Timestamp}
+	if chunk.Data == nil {
+		chunk.Data = []byte(event.Line + "\n")
+	}
+	return chunk
+}
+
+// OpenWorkloadSession starts an exec (or, with attach, an attach) session on
+// the compute-agent running start.WorkloadID. The returned func releases
+// the agent connection.
+func (c *Client) OpenWorkloadSession(ctx context.Context, start agentstream.ExecStart, attach bool) (*agentstream.Session, func(), error) {
+	conn, closeFn, err := c.WorkloadAgentConn(start.WorkloadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	open := agentstream.Exec
+	if attach {
+		open = agentstream.Attach
+	}
+	session, err := open(ctx, conn, start)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	return session, closeFn, nil
+}
+
+// PortForwarder opens tunnels to one workload over a shared agent
+// connection.
+type PortForwarder struct {
+	workloadID string
+	conn       grpc.ClientConnInterface
+	closeFn    func()
+}
+
+// NewPortForwarder connects to the compute-agent running workloadID.
+func (c *Client) NewPortForwarder(workloadID string) (*PortForwarder, error) {
+	conn, closeFn, err := c.WorkloadAgentConn(workloadID)
+	if err != nil {
+		return nil, err
+	}
+	return &PortForwarder{workloadID: workloadID, conn: conn, closeFn: closeFn}, nil
+}
+
+// Open starts a tunnel to port inside the workload.
+func (p *PortForwarder) Open(ctx context.Context, port uint32) (*agentstream.Tunnel, error) {
+	return agentstream.PortForward(ctx, p.conn, agentstream.PortForwardStart{WorkloadID: p.workloadID, Port: port})
+}
+
+// Close releases the agent connection.
+func (p *PortForwarder) Close() {
+	p.closeFn()
+}
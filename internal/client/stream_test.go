@@ -0,0 +1,75 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/agentstream"
+)
+
+func collectGatewayLogs(t *testing.T, body string, sse bool) []*agentstream.LogChunk {
+	t.Helper()
+	var chunks []*agentstream.LogChunk
+	err := readGatewayLogs(strings.NewReader(body), sse, func(c *agentstream.LogChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readGatewayLogs: %v", err)
+	}
+	return chunks
+}
+
+func TestReadGatewayLogsPlainText(t *testing.T) {
+	chunks := collectGatewayLogs(t, "first\nsecond", false)
+	if len(chunks) != 2 || string(chunks[0].Data) != "first\n" || string(chunks[1].Data) != "second\n" || chunks[0].Stream != "stdout" {
+		t.Fatalf("unexpected chunks: %+v", chunks)
+	}
+}
+
+func TestReadGatewayLogsSSE(t *testing.T) {
+	body := strings.Join([]string{
+		`: keep-alive`,
+		`event: log`,
+		`data: {"stream":"stderr","line":"boom","timestamp":"2026-10-18T10:00:00Z"}`,
+		``,
+		`data: {"stream":"stdout","data":"aGkK"}`,
+		``,
+		`data: raw line`,
+		`data: continued`,
+		``,
+		`data: {"unrelated":true}`,
+	}, "\n")
+	chunks := collectGatewayLogs(t, body, true)
+	if len(chunks) != 4 {
+		t.Fatalf("got %d chunks, want 4: %+v", len(chunks), chunks)
+	}
+	if c := chunks[0]; c.Stream != "stderr" || string(c.Data) != "boom\n" || !c.Timestamp.Equal(time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("line event = %+v", c)
+	}
+	if c := chunks[1]; c.Stream != "stdout" || string(c.Data) != "hi\n" {
+		t.Errorf("data event = %+v", c)
+	}
+	if c := chunks[2]; string(c.Data) != "raw line\ncontinued\n" {
+		t.Errorf("multi-line raw event = %q", c.Data)
+	}
+	// JSON without log fields is passed through as text; the last event has
+	// no trailing blank line.
+	if c := chunks[3]; string(c.Data) != "{\"unrelated\":true}\n" {
+		t.Errorf("unterminated event = %q", c.Data)
+	}
+}
+
+func TestReadGatewayLogsStopsOnCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := readGatewayLogs(strings.NewReader("a\nb\nc\n"), false, func(*agentstream.LogChunk) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("err = %v after %d calls, want stop after 1", err, calls)
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+
+	"github.com/persys-dev/persysctl/internal/agentstream"
+	"google.golang.org/grpc"
+)
+
+// withWorkloadAgent calls fn with a connection to the compute-agent hosting
+// workloadID and a context bounded by the configured RPC timeout.
+func (c *Client) withWorkloadAgent(workloadID string, fn func(context.Context, grpc.ClientConnInterface) error) error {
+	conn, closeFn, err := c.WorkloadAgentConn(workloadID)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	ctx, cancel := c.rpcContext()
+	defer cancel()
+	return fn(ctx, conn)
+}
+
+// CreateVMSnapshot snapshots a VM workload on its agent. Agents without the
+// VM lifecycle service return agentstream.ErrUnsupported.
+func (c *Client) CreateVMSnapshot(req agentstream.SnapshotRequest) (snap *agentstream.Snapshot, err error) {
+	err = c.withWorkloadAgent(req.WorkloadID, func(ctx context.Context, conn grpc.ClientConnInterface) error {
+		snap, err = agentstream.CreateSnapshot(ctx, conn, req)
+		return err
+	})
+	return snap, err
+}
+
+// ListVMSnapshots lists the snapshots of a VM workload.
+func (c *Client) ListVMSnapshots(workloadID string) (resp *agentstream.ListSnapshotsResponse, err error) {
+	err = c.withWorkloadAgent(workloadID, func(ctx context.Context, conn grpc.ClientConnInterface) error {
+		resp, err = agentstream.ListSnapshots(ctx, conn, agentstream.ListSnapshotsRequest{WorkloadID: workloadID})
+		return err
+	})
+	return resp, err
+}
+
+// RevertVMSnapshot reverts a VM workload to a snapshot.
+func (c *Client) RevertVMSnapshot(req agentstream.SnapshotRequest) (res *agentstream.VMOperationResult, err error) {
+	err = c.withWorkloadAgent(req.WorkloadID, func(ctx context.Context, conn grpc.ClientConnInterface) error {
+		res, err = agentstream.RevertSnapshot(ctx, conn, req)
+		return err
+	})
+	return res, err
+}
+
+// RebootVM reboots a VM workload through its agent.
+func (c *Client) RebootVM(req agentstream.RebootRequest) (res *agentstream.VMOperationResult, err error) {
+	err = c.withWorkloadAgent(req.WorkloadID, func(ctx context.Context, conn grpc.ClientConnInterface) error {
+		res, err = agentstream.Reboot(ctx, conn, req)
+		return err
+	})
+	return res, err
+}
@@ -26,6 +26,9 @@ type Config struct {
 	GRPCTarget        string
 	RPCTimeoutSeconds int
 
+	// Local revision history of applied workload specs
+	HistoryDir string
+
 	// Certificate settings
 	CACertPath   string
 	CertPath     string
@@ -95,6 +98,11 @@ func GetConfig() Config {
 		cfg.RPCTimeoutSeconds = 20
 	}
 
+	cfg.HistoryDir = strings.TrimSpace(stringWithEnv("history_dir", "PERSYS_HISTORY_DIR"))
+	if cfg.HistoryDir == "" {
+		cfg.HistoryDir = filepath.Join(os.Getenv("HOME"), ".persys", "history")
+	}
+
 	// Certificate settings
 	cfg.CACertPath = viper.GetString("ca_cert_path")
 	cfg.CertPath = viper.GetString("cert_path")
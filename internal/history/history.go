@@ -0,0 +1,257 @@
+// Package history records the workload specs persysctl has applied so that
+// earlier revisions can be listed and re-applied.
+//
+// Entries are stored locally, one JSON file per workload named after a hash
+// of the workload ID, under the configured history_dir (default
+// ~/.persys/history). Specs are stored exactly as sent
+// before secret resolution, so vault:/env: references stay references.
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the per-workload history file.
+const maxEntries = 50
+
+// Entry is one applied revision of a workload.
+type Entry struct {
+	WorkloadID   string          `json:"workloadId"`
+	RevisionID   string          `json:"revisionId"`
+	Type         string          `json:"type,omitempty"`
+	DesiredState string          `json:"desiredState,omitempty"`
+	Target       string          `json:"target,omitempty"`
+	Transport    string          `json:"transport,omitempty"`
+	AppliedAt    time.Time       `json:"appliedAt"`
+	Spec         json.RawMessage `json:"spec"`
+}
+
+// Store is a file-backed history store.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// ErrNotFound is returned when a workload or revision has no history entry.
+var ErrNotFound = errors.New("history: not found")
+
+// NewStore returns a store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// RevisionID derives a content-hash revision ID from a deterministic
+// encoding of a spec.
+func RevisionID(specBytes []byte) string {
+	sum := sha256.Sum256(specBytes)
+	return "rev-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Record appends e to the workload's history. Re-applying the revision that
+// is already latest only refreshes its timestamp.
+func (s *Store) Record(e Entry) error {
+	if strings.TrimSpace(e.WorkloadID) == "" || strings.TrimSpace(e.RevisionID) == "" {
+		return fmt.Errorf("history: workload and revision IDs are required")
+	}
+	if e.AppliedAt.IsZero() {
+		e.AppliedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load(e.WorkloadID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if n := len(entries); n > 0 && entries[n-1].RevisionID == e.RevisionID {
+		entries[n-1] = e
+	} else {
+		entries = append(entries, e)
+	}
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	return s.save(e.WorkloadID, entries)
+}
+
+// List returns the workload's history, oldest first.
+func (s *Store) List(workloadID string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(workloadID)
+}
+
+// Get returns the most recent entry with revisionID.
+func (s *Store) Get(workloadID, revisionID string) (*Entry, error) {
+	entries, err := s.List(workloadID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].RevisionID == revisionID {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: revision %s of workload %s", ErrNotFound, revisionID, workloadID)
+}
+
+// Previous returns the latest entry whose revision differs from the current
+// (latest) one, i.e. the default rollback target.
+func (s *Store) Previous(workloadID string) (*Entry, error) {
+	entries, err := s.List(workloadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: workload %s", ErrNotFound, workloadID)
+	}
+	current := entries[len(entries)-1].RevisionID
+	for i := len(entries) - 2; i >= 0; i-- {
+		if entries[i].RevisionID != current {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no revision before %s for workload %s", ErrNotFound, current, workloadID)
+}
+
+// Latest returns the most recent entry of every workload in the store,
+// ordered by workload ID. Workloads deleted since they were applied are
+// included.
+func (s *Store) Latest() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("history: read %s: %w", s.dir, err)
+	}
+	ids := map[string]bool{}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		entries, err := readEntries(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			ids[e.WorkloadID] = true
+		}
+	}
+	var out []Entry
+	for id := range ids {
+		// Go through load so that each workload's own file decides, not
+		// whichever file an entry happened to be found in.
+		entries, err := s.load(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			out = append(out, entries[len(entries)-1])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].WorkloadID < out[j].WorkloadID })
+	return out, nil
+}
+
+func (s *Store) path(workloadID string) string {
+	return filepath.Join(s.dir, fileName(workloadID)+".json")
+}
+
+// fileName maps a workload ID to a collision-free file name.
+func fileName(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "workload-" + hex.EncodeToString(sum[:])
+}
+
+// legacyFileName is the lossy name older releases used; it is only read.
+func legacyFileName(id string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(id)
+}
+
+// load returns workloadID's entries, falling back to a file written by an
+// older release. Entries of other workloads are dropped: legacy file names
+// were shared by IDs such as "a/b" and "a_b".
+func (s *Store) load(workloadID string) ([]Entry, error) {
+	entries, err := readEntries(s.path(workloadID))
+	if errors.Is(err, os.ErrNotExist) {
+		entries, err = readEntries(filepath.Join(s.dir, legacyFileName(workloadID)+".json"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: workload %s", ErrNotFound, workloadID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	own := entries[:0]
+	for _, e := range entries {
+		if e.WorkloadID == workloadID {
+			own = append(own, e)
+		}
+	}
+	if len(own) == 0 {
+		return nil, fmt.Errorf("%w: workload %s", ErrNotFound, workloadID)
+	}
+	return own, nil
+}
+
+func readEntries(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("history: read %s: %w", filepath.Base(path), err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("history: decode %s: %w", filepath.Base(path), err)
+	}
+	return entries, nil
+}
+
+func (s *Store) save(workloadID string, entries []Entry) error {
+	return writeJSON(s.dir, s.path(workloadID), entries)
+}
+
+// writeJSON atomically replaces path with the JSON encoding of v.
+func writeJSON(dir, path string, v any) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("history: create %s: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-history-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,145 @@
+package history_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/history"
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+func TestStoreRecordAndPrevious(t *testing.T) {
+	store := history.NewStore(t.TempDir())
+	spec := json.RawMessage(`{"type":"container"}`)
+
+	for _, rev := range []string{"rev-a", "rev-b", "rev-b"} {
+		if err := store.Record(history.Entry{WorkloadID: "web/1", RevisionID: rev, Spec: spec}); err != nil {
+			t.Fatalf("Record(%s): %v", rev, err)
+		}
+	}
+
+	entries, err := store.List("web/1")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected repeated apply of the latest revision to be collapsed, got %d entries", len(entries))
+	}
+
+	prev, err := store.Previous("web/1")
+	if err != nil {
+		t.Fatalf("Previous: %v", err)
+	}
+	if prev.RevisionID != "rev-a" {
+		t.Fatalf("Previous = %s, want rev-a", prev.RevisionID)
+	}
+
+	if _, err := store.Get("web/1", "rev-missing"); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("Get missing revision: expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.List("other"); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("List unknown workload: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRevisionIDIsStable(t *testing.T) {
+	a := history.RevisionID([]byte("spec"))
+	if a != history.RevisionID([]byte("spec")) {
+		t.Fatalf("RevisionID is not deterministic")
+	}
+	if a == history.RevisionID([]byte("spec2")) {
+		t.Fatalf("RevisionID collides for different specs")
+	}
+}
+
+func TestStoreMetricsByTarget(t *testing.T) {
+	store := history.NewStore(t.TempDir())
+	for i, target := range []string{"https://a", "https://b", "https://a"} {
+		snap := history.MetricsSnapshot{Target: target, Metrics: models.ClusterMetrics{FailedWorkloads: i}}
+		if err := store.RecordMetrics(snap); err != nil {
+			t.Fatalf("RecordMetrics: %v", err)
+		}
+	}
+
+	snaps, err := store.ListMetrics("https://a")
+	if err != nil {
+		t.Fatalf("ListMetrics: %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].Metrics.FailedWorkloads != 0 || snaps[1].Metrics.FailedWorkloads != 2 {
+		t.Fatalf("unexpected snapshots for target a: %+v", snaps)
+	}
+}
+
+func TestStoreLatest(t *testing.T) {
+	store := history.NewStore(t.TempDir())
+	spec := json.RawMessage(`{}`)
+	for _, e := range []history.Entry{
+		{WorkloadID: "web", RevisionID: "rev-a", Spec: spec},
+		{WorkloadID: "api/v2", RevisionID: "rev-c", Spec: spec},
+		{WorkloadID: "web", RevisionID: "rev-b", Spec: spec},
+	} {
+		if err := store.Record(e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := store.RecordMetrics(history.MetricsSnapshot{}); err != nil {
+		t.Fatalf("RecordMetrics: %v", err)
+	}
+
+	latest, err := store.Latest()
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if len(latest) != 2 || latest[0].WorkloadID != "api/v2" || latest[1].RevisionID != "rev-b" {
+		t.Fatalf("Latest = %+v, want api/v2 and web@rev-b", latest)
+	}
+}
+
+func TestStoreKeepsSimilarIDsApart(t *testing.T) {
+	store := history.NewStore(t.TempDir())
+	ids := []string{"a/b", `a\b`, "a..b", "a_b"}
+	for _, id := range ids {
+		if err := store.Record(history.Entry{WorkloadID: id, RevisionID: "rev-" + id, Spec: json.RawMessage(`{}`)}); err != nil {
+			t.Fatalf("Record(%s): %v", id, err)
+		}
+	}
+	for _, id := range ids {
+		entries, err := store.List(id)
+		if err != nil || len(entries) != 1 || entries[0].WorkloadID != id || entries[0].RevisionID != "rev-"+id {
+			t.Errorf("List(%s) = %+v, %v; want only its own entry", id, entries, err)
+		}
+	}
+	latest, err := store.Latest()
+	if err != nil || len(latest) != len(ids) {
+		t.Fatalf("Latest = %+v, %v; want %d workloads", latest, err, len(ids))
+	}
+}
+
+func TestStoreReadsLegacyFiles(t *testing.T) {
+	dir := t.TempDir()
+	// Older releases stored "a/b" and "a_b" together in a_b.json.
+	legacy := `[{"workloadId":"a/b","revisionId":"rev-1","spec":{}},{"workloadId":"a_b","revisionId":"rev-2","spec":{}}]`
+	if err := os.WriteFile(filepath.Join(dir, "a_b.json"), []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := history.NewStore(dir)
+
+	entries, err := store.List("a/b")
+	if err != nil || len(entries) != 1 || entries[0].RevisionID != "rev-1" {
+		t.Fatalf("List(a/b) = %+v, %v; want rev-1 only", entries, err)
+	}
+	if err := store.Record(history.Entry{WorkloadID: "a_b", RevisionID: "rev-3", Spec: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = store.List("a_b")
+	if err != nil || len(entries) != 2 || entries[0].RevisionID != "rev-2" || entries[1].RevisionID != "rev-3" {
+		t.Fatalf("List(a_b) = %+v, %v; want rev-2, rev-3", entries, err)
+	}
+	latest, err := store.Latest()
+	if err != nil || len(latest) != 2 || latest[0].RevisionID != "rev-1" || latest[1].RevisionID != "rev-3" {
+		t.Fatalf("Latest = %+v, %v", latest, err)
+	}
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/persys-dev/persysctl/internal/models"
+)
+
+// maxMetricsSnapshots bounds the cluster metrics history file.
+const maxMetricsSnapshots = 500
+
+// MetricsSnapshot is one recorded `persysctl metrics` result.
+type MetricsSnapshot struct {
+	RecordedAt time.Time             `json:"recordedAt"`
+	Target     string                `json:"target,omitempty"`
+	Metrics    models.ClusterMetrics `json:"metrics"`
+}
+
+func (s *Store) metricsDir() string {
+	return filepath.Join(s.dir, "metrics")
+}
+
+func (s *Store) metricsPath() string {
+	return filepath.Join(s.metricsDir(), "cluster.json")
+}
+
+// RecordMetrics appends a cluster metrics snapshot, keeping the newest
+// maxMetricsSnapshots.
+func (s *Store) RecordMetrics(snap MetricsSnapshot) error {
+	if snap.RecordedAt.IsZero() {
+		snap.RecordedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snaps, err := s.loadMetrics()
+	if err != nil {
+		return err
+	}
+	snaps = append(snaps, snap)
+	if len(snaps) > maxMetricsSnapshots {
+		snaps = snaps[len(snaps)-maxMetricsSnapshots:]
+	}
+	return writeJSON(s.metricsDir(), s.metricsPath(), snaps)
+}
+
+// ListMetrics returns recorded snapshots for target, oldest first. An empty
+// target returns snapshots for every target.
+func (s *Store) ListMetrics(target string) ([]MetricsSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snaps, err := s.loadMetrics()
+	if err != nil || target == "" {
+		return snaps, err
+	}
+	out := snaps[:0]
+	for _, snap := range snaps {
+		if snap.Target == target {
+			out = append(out, snap)
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) loadMetrics() ([]MetricsSnapshot, error) {
+	data, err := os.ReadFile(s.metricsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("history: read metrics: %w", err)
+	}
+	var snaps []MetricsSnapshot
+	if err := json.Unmarshal(data, &snaps); err != nil {
+		return nil, fmt.Errorf("history: decode metrics: %w", err)
+	}
+	return snaps, nil
+}
@@ -0,0 +1,387 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyPatches resolves a kustomize-style overlay: base is a YAML or JSON
+// document and each patch is applied to it in order. The result is always
+// returned as JSON so it can feed the existing spec converters.
+//
+// A patch whose top level is a list of {"op": ...} objects is treated as an
+// RFC 6902 JSON Patch. Any other document is a strategic merge patch: maps
+// are merged recursively, a null value deletes the key, and lists of objects
+// that all carry a "name" field are merged by name (an item containing
+// "$patch: delete" removes the matching entry). Other lists are replaced.
+func ApplyPatches(base []byte, patches ...[]byte) ([]byte, error) {
+	doc, err := decodeDocument(base)
+	if err != nil {
+		return nil, fmt.Errorf("ingestion: parse base manifest: %w", err)
+	}
+	for i, raw := range patches {
+		patch, err := decodeDocument(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ingestion: parse patch %d: %w", i+1, err)
+		}
+		if ops, ok := jsonPatchOps(patch); ok {
+			doc, err = applyJSONPatch(doc, ops)
+			if err != nil {
+				return nil, fmt.Errorf("ingestion: apply JSON patch %d: %w", i+1, err)
+			}
+			continue
+		}
+		doc = strategicMerge(doc, patch)
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("ingestion: encode patched manifest: %w", err)
+	}
+	return out, nil
+}
+
+func decodeDocument(data []byte) (any, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func jsonPatchOps(doc any) ([]map[string]any, bool) {
+	list, ok := doc.([]any)
+	if !ok || len(list) == 0 {
+		return nil, false
+	}
+	ops := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		op, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := op["op"].(string); !ok {
+			return nil, false
+		}
+		ops = append(ops, op)
+	}
+	return ops, true
+}
+
+func strategicMerge(dst, patch any) any {
+	patchMap, ok := patch.(map[string]any)
+	if !ok {
+		if patchList, isList := patch.([]any); isList {
+			if dstList, isDstList := dst.([]any); isDstList {
+				if merged, ok := mergeNamedLists(dstList, patchList); ok {
+					return merged
+				}
+			}
+		}
+		return patch
+	}
+	dstMap, ok := dst.(map[string]any)
+	if !ok {
+		dstMap = map[string]any{}
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(dstMap, k)
+			continue
+		}
+		dstMap[k] = strategicMerge(dstMap[k], v)
+	}
+	return dstMap
+}
+
+func mergeNamedLists(dst, patch []any) ([]any, bool) {
+	if !allNamed(dst) || !allNamed(patch) {
+		return nil, false
+	}
+	out := append([]any{}, dst...)
+	for _, item := range patch {
+		p := item.(map[string]any)
+		idx := -1
+		for i, existing := range out {
+			if existing.(map[string]any)["name"] == p["name"] {
+				idx = i
+				break
+			}
+		}
+		if p["$patch"] == "delete" {
+			if idx >= 0 {
+				out = append(out[:idx], out[idx+1:]...)
+			}
+			continue
+		}
+		if idx < 0 {
+			out = append(out, p)
+			continue
+		}
+		out[idx] = strategicMerge(out[idx], p)
+	}
+	return out, true
+}
+
+func allNamed(list []any) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func applyJSONPatch(doc any, ops []map[string]any) (any, error) {
+	var err error
+	for _, op := range ops {
+		name, _ := op["op"].(string)
+		path, _ := op["path"].(string)
+		switch name {
+		case "add":
+			doc, err = pointerAdd(doc, path, op["value"])
+		case "remove":
+			doc, _, err = pointerRemove(doc, path)
+		case "replace":
+			if doc, _, err = pointerRemove(doc, path); err == nil {
+				doc, err = pointerAdd(doc, path, op["value"])
+			}
+		case "move", "copy":
+			from, _ := op["from"].(string)
+			var v any
+			if name == "move" {
+				doc, v, err = pointerRemove(doc, from)
+			} else {
+				v, err = pointerGet(doc, from)
+				v = deepCopy(v)
+			}
+			if err == nil {
+				doc, err = pointerAdd(doc, path, v)
+			}
+		case "test":
+			var v any
+			if v, err = pointerGet(doc, path); err == nil && !reflect.DeepEqual(normalizeNumbers(v), normalizeNumbers(op["value"])) {
+				err = fmt.Errorf("test failed at %q", path)
+			}
+		default:
+			err = fmt.Errorf("unsupported op %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", name, path, err)
+		}
+	}
+	return doc, nil
+}
+
+func splitPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", path)
+	}
+	parts := strings.Split(path[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func pointerGet(doc any, path string) (any, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, p := range parts {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[p]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", path)
+			}
+			cur = v
+		case []any:
+			idx, err := listIndex(p, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("path %q not found", path)
+		}
+	}
+	return cur, nil
+}
+
+// pointerAdd returns the updated document because inserting into a list
+// may reallocate it, which the parent container has to observe.
+func pointerAdd(doc any, path string, v any) (any, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return v, nil
+	}
+	return addAt(doc, parts, v)
+}
+
+func addAt(node any, parts []string, v any) (any, error) {
+	key := parts[0]
+	last := len(parts) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		if last {
+			n[key] = v
+			return n, nil
+		}
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q not found", key)
+		}
+		updated, err := addAt(child, parts[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = updated
+		return n, nil
+	case []any:
+		if last {
+			idx, err := listIndex(key, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[idx+1:], n[idx:])
+			n[idx] = v
+			return n, nil
+		}
+		idx, err := listIndex(key, len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := addAt(n[idx], parts[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot add into %T at %q", node, key)
+	}
+}
+
+func pointerRemove(doc any, path string) (any, any, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(parts) == 0 {
+		return nil, doc, nil
+	}
+	return removeAt(doc, parts)
+}
+
+func removeAt(node any, parts []string) (any, any, error) {
+	key := parts[0]
+	last := len(parts) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("path segment %q not found", key)
+		}
+		if last {
+			delete(n, key)
+			return n, child, nil
+		}
+		updated, removed, err := removeAt(child, parts[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[key] = updated
+		return n, removed, nil
+	case []any:
+		idx, err := listIndex(key, len(n), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[idx]
+			return append(n[:idx], n[idx+1:]...), removed, nil
+		}
+		updated, removed, err := removeAt(n[idx], parts[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[idx] = updated
+		return n, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("cannot remove from %T at %q", node, key)
+	}
+}
+
+func listIndex(key string, length int, allowEnd bool) (int, error) {
+	if key == "-" && allowEnd {
+		return length, nil
+	}
+	idx, err := strconv.Atoi(key)
+	if err != nil || idx < 0 || idx > length || (idx == length && !allowEnd) {
+		return 0, fmt.Errorf("invalid list index %q", key)
+	}
+	return idx, nil
+}
+
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			out[k] = deepCopy(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, val := range t {
+			out[i] = deepCopy(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// normalizeNumbers makes YAML ints and floats comparable for "test" ops.
+func normalizeNumbers(v any) any {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			out[k] = normalizeNumbers(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, val := range t {
+			out[i] = normalizeNumbers(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,179 @@
+package ingestion_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/ingestion"
+)
+
+var baseSpec = []byte(`{
+  "image": "nginx:1.27-alpine",
+  "env": {"MODE": "dev", "DEBUG": "1"},
+  "ports": [{"host_port": 8080, "container_port": 80}]
+}`)
+
+func TestLoadValues_MergesFilesAndOverrides(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "values.yaml")
+	prod := filepath.Join(dir, "values-prod.yaml")
+	if err := os.WriteFile(base, []byte("image:\n  repo: nginx\n  tag: latest\nreplicas: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(prod, []byte("image:\n  tag: \"1.27\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := ingestion.LoadValues([]string{base, prod}, []string{"replicas=3", "env.MODE=prod"}, nil)
+	if err != nil {
+		t.Fatalf("LoadValues: %v", err)
+	}
+	image := values["image"].(map[string]any)
+	if image["repo"] != "nginx" || image["tag"] != "1.27" {
+		t.Errorf("unexpected image values: %v", image)
+	}
+	if values["replicas"] != 3 {
+		t.Errorf("expected replicas 3, got %#v", values["replicas"])
+	}
+	if values["env"].(map[string]any)["MODE"] != "prod" {
+		t.Errorf("expected env.MODE override, got %v", values["env"])
+	}
+}
+
+func TestLoadValues_RejectsMalformedSet(t *testing.T) {
+	if _, err := ingestion.LoadValues(nil, []string{"noequals"}, nil); err == nil {
+		t.Fatal("expected error for --set without '='")
+	}
+	if _, err := ingestion.LoadValues(nil, nil, []string{"=x"}); err == nil {
+		t.Fatal("expected error for --set-string without a key")
+	}
+}
+
+func TestLoadValues_KeepsVersionLikeValuesAsStrings(t *testing.T) {
+	values, err := ingestion.LoadValues(nil,
+		[]string{"image.tag=1.10", "old=1.0", "id=123456789012345678901234", "zip=0123", "debug=true", "port=8080", "neg=-2"},
+		[]string{"replicas=3", "flag=false"})
+	if err != nil {
+		t.Fatalf("LoadValues: %v", err)
+	}
+	want := map[string]any{
+		"old": "1.0", "id": "123456789012345678901234", "zip": "0123", "debug": true, "port": 8080, "neg": -2,
+		"replicas": "3", "flag": "false",
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, values[k], v)
+		}
+	}
+
+	out, err := ingestion.Render([]byte(`{"image": "nginx:{{ .Values.image.tag }}"}`), values)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if string(out) != `{"image": "nginx:1.10"}` {
+		t.Errorf("Render = %s, want the tag kept as 1.10", out)
+	}
+}
+
+func TestRender_SubstitutesValues(t *testing.T) {
+	tmpl := []byte(`{"image": "{{ .Values.repo }}:{{ .Values.tag | default "latest" }}", "note": "<no value> {{ .Values.note }}"}`)
+	out, err := ingestion.Render(tmpl, ingestion.Values{"repo": "nginx", "tag": nil, "note": ""})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := `{"image": "nginx:latest", "note": "<no value> "}`
+	if string(out) != want {
+		t.Errorf("Render = %s, want %s", out, want)
+	}
+}
+
+func TestRender_MissingKeyFails(t *testing.T) {
+	_, err := ingestion.Render([]byte(`{"image": "{{ .Values.repo }}"}`), ingestion.Values{})
+	if err == nil || !strings.Contains(err.Error(), "repo") {
+		t.Fatalf("expected error naming the missing key, got %v", err)
+	}
+}
+
+func TestRender_RequiredFails(t *testing.T) {
+	_, err := ingestion.Render([]byte(`{{ required "tag is required" .Values.tag }}`), ingestion.Values{"tag": ""})
+	if err == nil {
+		t.Fatal("expected error for missing required value")
+	}
+}
+
+func TestApplyPatches_StrategicMerge(t *testing.T) {
+	patch := []byte("env:\n  MODE: prod\n  DEBUG: null\nimage: nginx:1.27\n")
+	out, err := ingestion.ApplyPatches(baseSpec, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatches: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	env := got["env"].(map[string]any)
+	if env["MODE"] != "prod" {
+		t.Errorf("expected MODE=prod, got %v", env["MODE"])
+	}
+	if _, ok := env["DEBUG"]; ok {
+		t.Error("expected DEBUG to be deleted by null")
+	}
+	if got["image"] != "nginx:1.27" {
+		t.Errorf("expected image override, got %v", got["image"])
+	}
+}
+
+func TestApplyPatches_StrategicMergeNamedLists(t *testing.T) {
+	base := []byte(`{"disks": [{"name": "root", "size_gb": 5}, {"name": "scratch", "size_gb": 1}]}`)
+	patch := []byte(`{"disks": [{"name": "root", "size_gb": 20}, {"name": "scratch", "$patch": "delete"}, {"name": "data", "size_gb": 50}]}`)
+	out, err := ingestion.ApplyPatches(base, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatches: %v", err)
+	}
+	want := `{"disks":[{"name":"root","size_gb":20},{"name":"data","size_gb":50}]}`
+	if string(out) != want {
+		t.Errorf("ApplyPatches = %s, want %s", out, want)
+	}
+}
+
+func TestApplyPatches_JSONPatch(t *testing.T) {
+	patch := []byte(`[
+  {"op": "test", "path": "/ports/0/container_port", "value": 80},
+  {"op": "replace", "path": "/ports/0/host_port", "value": 9090},
+  {"op": "add", "path": "/env/REGION", "value": "eu-west"},
+  {"op": "remove", "path": "/env/DEBUG"},
+  {"op": "copy", "from": "/image", "path": "/labels"}
+]`)
+	out, err := ingestion.ApplyPatches(baseSpec, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatches: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	port := got["ports"].([]any)[0].(map[string]any)
+	if port["host_port"] != float64(9090) {
+		t.Errorf("expected host_port 9090, got %v", port["host_port"])
+	}
+	env := got["env"].(map[string]any)
+	if env["REGION"] != "eu-west" {
+		t.Errorf("expected REGION to be added, got %v", env)
+	}
+	if _, ok := env["DEBUG"]; ok {
+		t.Error("expected DEBUG to be removed")
+	}
+	if got["labels"] != "nginx:1.27-alpine" {
+		t.Errorf("expected copied value, got %v", got["labels"])
+	}
+}
+
+func TestApplyPatches_JSONPatchTestFailure(t *testing.T) {
+	patch := []byte(`[{"op": "test", "path": "/image", "value": "redis"}]`)
+	if _, err := ingestion.ApplyPatches(baseSpec, patch); err == nil {
+		t.Fatal("expected failed test op to return an error")
+	}
+}
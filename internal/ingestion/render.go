@@ -0,0 +1,170 @@
+package ingestion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Values holds template inputs merged from values files and --set overrides.
+type Values map[string]any
+
+// LoadValues merges the given YAML or JSON values files in order and then
+// applies key=value overrides, followed by stringOverrides. Override keys use
+// dotted paths (e.g. "image.tag=1.27"). Overrides only become booleans or
+// integers when the value is exactly "true", "false" or a canonical integer,
+// so "replicas=3" yields an integer while "tag=1.10" stays a string;
+// stringOverrides are never typed.
+//
+//	values, err := ingestion.LoadValues([]string{"values-prod.yaml"}, []string{"image.tag=1.27"}, nil)
+func LoadValues(files []string, overrides []string, stringOverrides []string) (Values, error) {
+	values := Values{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("ingestion: read values file %q: %w", file, err)
+		}
+		var doc map[string]any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("ingestion: parse values file %q: %w", file, err)
+		}
+		mergeValues(values, doc)
+	}
+	if err := applyOverrides(values, "--set", overrides, parseScalar); err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(values, "--set-string", stringOverrides, func(raw string) any { return raw }); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func applyOverrides(values Values, flag string, overrides []string, parse func(string) any) error {
+	for _, override := range overrides {
+		key, raw, ok := strings.Cut(override, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("ingestion: invalid %s %q (expected key=value)", flag, override)
+		}
+		if err := setValue(values, strings.Split(key, "."), parse(raw)); err != nil {
+			return fmt.Errorf("ingestion: %s %q: %w", flag, override, err)
+		}
+	}
+	return nil
+}
+
+// Render executes data as a Go text/template with values available as
+// .Values. Referencing a key that no values file or override sets is an
+// error; "default" and "required" apply to keys that are set but empty.
+//
+//	{"image": "nginx:{{ .Values.image.tag | default \"latest\" }}"}
+func Render(data []byte, values Values) ([]byte, error) {
+	tmpl, err := template.New("spec").Funcs(templateFuncs()).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("ingestion: parse template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, map[string]any{"Values": map[string]any(values)}); err != nil {
+		return nil, fmt.Errorf("ingestion: render template: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default": func(fallback, v any) any {
+			if isEmptyValue(v) {
+				return fallback
+			}
+			return v
+		},
+		"required": func(msg string, v any) (any, error) {
+			if isEmptyValue(v) {
+				return nil, fmt.Errorf("%s", msg)
+			}
+			return v, nil
+		},
+		"quote": func(v any) string {
+			return strconv.Quote(fmt.Sprint(v))
+		},
+		"toJson": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+	}
+}
+
+func isEmptyValue(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case map[string]any:
+		return len(t) == 0
+	case []any:
+		return len(t) == 0
+	default:
+		return false
+	}
+}
+
+// mergeValues deep-merges src into dst; later files win on conflicts.
+func mergeValues(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func setValue(dst map[string]any, path []string, v any) error {
+	for i, key := range path {
+		if key == "" {
+			return fmt.Errorf("empty path segment")
+		}
+		if i == len(path)-1 {
+			dst[key] = v
+			return nil
+		}
+		next, ok := dst[key].(map[string]any)
+		if !ok {
+			if _, exists := dst[key]; exists {
+				return fmt.Errorf("%q is not a map", strings.Join(path[:i+1], "."))
+			}
+			next = map[string]any{}
+			dst[key] = next
+		}
+		dst = next
+	}
+	return nil
+}
+
+// parseScalar types an override value. Only values that print back exactly
+// as given become booleans or integers; anything else, including floats such
+// as version numbers ("1.10"), stays a string.
+func parseScalar(raw string) any {
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if n, err := strconv.Atoi(raw); err == nil && strconv.Itoa(n) == raw {
+		return n
+	}
+	return raw
+}
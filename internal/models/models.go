@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -77,10 +78,29 @@ type WorkloadUsage struct {
 }
 
 type Node struct {
-	NodeID        string            `json:"nodeId"`
-	IPAddress     string            `json:"ipAddress"`
-	Status        string            `json:"status"`
-	LastHeartbeat time.Time         `json:"lastHeartbeat"`
-	Resources     Resources         `json:"resources"`
-	Labels        map[string]string `json:"labels,omitempty"`
+	NodeID                 string            `json:"nodeId"`
+	IPAddress              string            `json:"ipAddress"`
+	Status                 string            `json:"status"`
+	StatusReason           string            `json:"statusReason,omitempty"`
+	LastHeartbeat          time.Time         `json:"lastHeartbeat"`
+	Resources              Resources         `json:"resources"`
+	Available              Resources         `json:"available"`
+	SupportedWorkloadTypes []string          `json:"supportedWorkloadTypes,omitempty"`
+	Labels                 map[string]string `json:"labels,omitempty"`
+}
+
+// ClusterMetrics is the cluster summary returned by both the scheduler's
+// GetClusterSummary RPC and the gateway's /cluster/metrics route. Fields the
+// gateway returns beyond the summary are kept verbatim in Extensions.
+type ClusterMetrics struct {
+	TotalNodes       int                        `json:"totalNodes"`
+	ReadyNodes       int                        `json:"readyNodes"`
+	NotReadyNodes    int                        `json:"notReadyNodes"`
+	TotalWorkloads   int                        `json:"totalWorkloads"`
+	RunningWorkloads int                        `json:"runningWorkloads"`
+	PendingWorkloads int                        `json:"pendingWorkloads"`
+	FailedWorkloads  int                        `json:"failedWorkloads"`
+	DeletedWorkloads int                        `json:"deletedWorkloads"`
+	GeneratedAt      time.Time                  `json:"generatedAt,omitempty"`
+	Extensions       map[string]json.RawMessage `json:"extensions,omitempty"`
 }
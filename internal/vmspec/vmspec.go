@@ -0,0 +1,456 @@
+// Package vmspec builds compute-agent VM specs from a small declarative
+// description and renders their cloud-init documents, so VM specs no longer
+// need hand-escaped cloud-config strings.
+//
+// A VM file is YAML (or JSON):
+//
+//	name: web-vm
+//	vcpus: 2
+//	memoryMb: 4096
+//	disks:
+//	  - path: /var/lib/libvirt/images/ubuntu-22.04.qcow2
+//	    format: qcow2
+//	    sizeGb: 20
+//	    boot: true
+//	networks:
+//	  - network: default
+//	    mac: 52:54:00:12:34:56
+//	    ip: 10.0.0.10/24
+//	    gateway: 10.0.0.1
+//	users:
+//	  - name: ops
+//	    sudo: ALL=(ALL) NOPASSWD:ALL
+//	    sshAuthorizedKeys: ["ssh-ed25519 AAAA..."]
+//	packages: [qemu-guest-agent]
+//	runcmd: [systemctl enable --now qemu-guest-agent]
+//
+// The generated spec carries only cloud_init_config (user_data, meta_data and
+// network_config); the legacy cloud_init string is never emitted.
+package vmspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VM describes a virtual machine workload.
+type VM struct {
+	Name              string            `yaml:"name" json:"name"`
+	Hostname          string            `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	Vcpus             int32             `yaml:"vcpus" json:"vcpus"`
+	MemoryMB          int64             `yaml:"memoryMb" json:"memoryMb"`
+	Disks             []Disk            `yaml:"disks" json:"disks"`
+	Networks          []Network         `yaml:"networks,omitempty" json:"networks,omitempty"`
+	Users             []User            `yaml:"users,omitempty" json:"users,omitempty"`
+	SSHAuthorizedKeys []string          `yaml:"sshAuthorizedKeys,omitempty" json:"sshAuthorizedKeys,omitempty"`
+	Packages          []string          `yaml:"packages,omitempty" json:"packages,omitempty"`
+	Runcmd            []string          `yaml:"runcmd,omitempty" json:"runcmd,omitempty"`
+	Metadata          map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// Disk is a VM disk.
+type Disk struct {
+	Path   string `yaml:"path" json:"path"`
+	Device string `yaml:"device,omitempty" json:"device,omitempty"`
+	Format string `yaml:"format,omitempty" json:"format,omitempty"` // qcow2 (default), raw, iso
+	Type   string `yaml:"type,omitempty" json:"type,omitempty"`     // disk (default), cdrom
+	SizeGB int64  `yaml:"sizeGb,omitempty" json:"sizeGb,omitempty"`
+	Boot   bool   `yaml:"boot,omitempty" json:"boot,omitempty"`
+}
+
+// Network is a VM network interface. IP, Gateway and Nameservers only shape
+// the guest network_config; without IP the interface uses DHCP.
+type Network struct {
+	Network     string   `yaml:"network" json:"network"`
+	MAC         string   `yaml:"mac,omitempty" json:"mac,omitempty"`
+	IP          string   `yaml:"ip,omitempty" json:"ip,omitempty"` // CIDR, e.g. 10.0.0.10/24
+	Gateway     string   `yaml:"gateway,omitempty" json:"gateway,omitempty"`
+	Nameservers []string `yaml:"nameservers,omitempty" json:"nameservers,omitempty"`
+}
+
+// User is a guest user created by cloud-init.
+type User struct {
+	Name              string   `yaml:"name" json:"name"`
+	Groups            []string `yaml:"groups,omitempty" json:"groups,omitempty"`
+	Sudo              string   `yaml:"sudo,omitempty" json:"sudo,omitempty"`
+	Shell             string   `yaml:"shell,omitempty" json:"shell,omitempty"`
+	SSHAuthorizedKeys []string `yaml:"sshAuthorizedKeys,omitempty" json:"sshAuthorizedKeys,omitempty"`
+}
+
+var (
+	diskFormats  = map[string]bool{"qcow2": true, "raw": true, "iso": true}
+	diskTypes    = map[string]bool{"disk": true, "cdrom": true}
+	deviceName   = regexp.MustCompile(`^(vd|sd|hd)[a-z]{1,2}$`)
+	hostnameChar = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+)
+
+// LoadFile reads a VM description from a YAML or JSON file.
+func LoadFile(path string) (*VM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vm := &VM{}
+	if err := yaml.Unmarshal(data, vm); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vm, nil
+}
+
+// Normalize fills defaults: disk format (from the file extension, else
+// qcow2) and type, device names in order (vda, vdb, ...; sda for cdroms),
+// lower-case MAC addresses and the hostname.
+func (v *VM) Normalize() {
+	if v.Hostname == "" {
+		v.Hostname = strings.ToLower(v.Name)
+	}
+	used := map[string]bool{}
+	for _, d := range v.Disks {
+		if d.Device != "" {
+			used[d.Device] = true
+		}
+	}
+	next := map[string]int{}
+	for i := range v.Disks {
+		d := &v.Disks[i]
+		d.Format = strings.ToLower(strings.TrimSpace(d.Format))
+		d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+		if d.Format == "" {
+			switch {
+			case strings.HasSuffix(strings.ToLower(d.Path), ".iso"):
+				d.Format = "iso"
+			case strings.HasSuffix(strings.ToLower(d.Path), ".raw"), d.Type == "cdrom":
+				d.Format = "raw"
+			default:
+				d.Format = "qcow2"
+			}
+		}
+		if d.Type == "" {
+			d.Type = "disk"
+			if d.Format == "iso" {
+				d.Type = "cdrom"
+			}
+		}
+		if d.Device == "" {
+			prefix := "vd"
+			if d.Type == "cdrom" {
+				prefix = "sd"
+			}
+			for {
+				name := prefix + string(rune('a'+next[prefix]))
+				next[prefix]++
+				if !used[name] {
+					d.Device, used[name] = name, true
+					break
+				}
+			}
+		}
+	}
+	for i := range v.Networks {
+		if mac, err := net.ParseMAC(v.Networks[i].MAC); err == nil {
+			v.Networks[i].MAC = mac.String()
+		}
+	}
+}
+
+// Validate checks the description, including disk formats and MAC
+// addresses. Call Normalize first.
+func (v *VM) Validate() error {
+	if strings.TrimSpace(v.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !hostnameChar.MatchString(v.Hostname) {
+		return fmt.Errorf("hostname %q is not a valid host name", v.Hostname)
+	}
+	if v.Vcpus <= 0 {
+		return fmt.Errorf("vcpus must be positive")
+	}
+	if v.MemoryMB <= 0 {
+		return fmt.Errorf("memoryMb must be positive")
+	}
+	if len(v.Disks) == 0 {
+		return fmt.Errorf("at least one disk is required")
+	}
+
+	devices := map[string]bool{}
+	boot := 0
+	for i, d := range v.Disks {
+		if strings.TrimSpace(d.Path) == "" {
+			return fmt.Errorf("disk %d: path is required", i)
+		}
+		if !diskFormats[d.Format] {
+			return fmt.Errorf("disk %d: unsupported format %q (expected qcow2, raw or iso)", i, d.Format)
+		}
+		if !diskTypes[d.Type] {
+			return fmt.Errorf("disk %d: unsupported type %q (expected disk or cdrom)", i, d.Type)
+		}
+		if d.Format == "iso" && d.Type != "cdrom" {
+			return fmt.Errorf("disk %d: iso images must use type cdrom", i)
+		}
+		if d.SizeGB < 0 {
+			return fmt.Errorf("disk %d: sizeGb must not be negative", i)
+		}
+		if !deviceName.MatchString(d.Device) {
+			return fmt.Errorf("disk %d: invalid device name %q (expected e.g. vda, sdb)", i, d.Device)
+		}
+		if devices[d.Device] {
+			return fmt.Errorf("disk %d: device %s is used twice", i, d.Device)
+		}
+		devices[d.Device] = true
+		if d.Boot {
+			boot++
+		}
+	}
+	if boot > 1 {
+		return fmt.Errorf("only one disk can be the boot disk, %d are", boot)
+	}
+
+	macs := map[string]bool{}
+	for i, n := range v.Networks {
+		if strings.TrimSpace(n.Network) == "" {
+			return fmt.Errorf("network %d: network name is required", i)
+		}
+		if n.MAC != "" {
+			if err := ValidateMAC(n.MAC); err != nil {
+				return fmt.Errorf("network %d: %w", i, err)
+			}
+			if macs[n.MAC] {
+				return fmt.Errorf("network %d: mac %s is used twice", i, n.MAC)
+			}
+			macs[n.MAC] = true
+		}
+		if n.MAC == "" && len(v.Networks) > 1 {
+			return fmt.Errorf("network %d: a mac is required on VMs with several networks to match the guest interface", i)
+		}
+		if n.IP != "" {
+			if _, _, err := net.ParseCIDR(n.IP); err != nil {
+				return fmt.Errorf("network %d: ip %q must be in CIDR form, e.g. 10.0.0.10/24", i, n.IP)
+			}
+		}
+		if n.Gateway != "" && net.ParseIP(n.Gateway) == nil {
+			return fmt.Errorf("network %d: invalid gateway %q", i, n.Gateway)
+		}
+		for _, ns := range n.Nameservers {
+			if net.ParseIP(ns) == nil {
+				return fmt.Errorf("network %d: invalid nameserver %q", i, ns)
+			}
+		}
+	}
+
+	for i, u := range v.Users {
+		if strings.TrimSpace(u.Name) == "" {
+			return fmt.Errorf("user %d: name is required", i)
+		}
+	}
+	return nil
+}
+
+// ValidateMAC accepts a 48-bit unicast MAC address.
+func ValidateMAC(s string) error {
+	mac, err := net.ParseMAC(s)
+	if err != nil || len(mac) != 6 {
+		return fmt.Errorf("invalid mac address %q", s)
+	}
+	if mac[0]&1 == 1 {
+		return fmt.Errorf("mac address %s is multicast; use a unicast address such as 52:54:00:xx:xx:xx", s)
+	}
+	return nil
+}
+
+// UserData renders the #cloud-config user data.
+func (v *VM) UserData() (string, error) {
+	type cloudUser struct {
+		Name              string   `yaml:"name"`
+		Groups            string   `yaml:"groups,omitempty"`
+		Sudo              string   `yaml:"sudo,omitempty"`
+		Shell             string   `yaml:"shell,omitempty"`
+		SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+	}
+	doc := struct {
+		Hostname          string   `yaml:"hostname"`
+		ManageEtcHosts    bool     `yaml:"manage_etc_hosts"`
+		Users             []any    `yaml:"users,omitempty"`
+		SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+		PackageUpdate     bool     `yaml:"package_update,omitempty"`
+		Packages          []string `yaml:"packages,omitempty"`
+		Runcmd            []string `yaml:"runcmd,omitempty"`
+	}{
+		Hostname:          v.Hostname,
+		ManageEtcHosts:    true,
+		SSHAuthorizedKeys: v.SSHAuthorizedKeys,
+		PackageUpdate:     len(v.Packages) > 0,
+		Packages:          v.Packages,
+		Runcmd:            v.Runcmd,
+	}
+	if len(v.Users) > 0 {
+		// Keep the image's default user next to the declared ones.
+		doc.Users = append(doc.Users, "default")
+		for _, u := range v.Users {
+			doc.Users = append(doc.Users, cloudUser{
+				Name:              u.Name,
+				Groups:            strings.Join(u.Groups, ", "),
+				Sudo:              u.Sudo,
+				Shell:             u.Shell,
+				SSHAuthorizedKeys: u.SSHAuthorizedKeys,
+			})
+		}
+	}
+	out, err := marshalYAML(doc)
+	if err != nil {
+		return "", err
+	}
+	return "#cloud-config\n" + out, nil
+}
+
+// MetaData renders the NoCloud meta data.
+func (v *VM) MetaData(instanceID string) (string, error) {
+	out, err := json.Marshal(map[string]string{
+		"instance-id":    instanceID,
+		"local-hostname": v.Hostname,
+	})
+	return string(out), err
+}
+
+// NetworkConfig renders a netplan version 2 network config, or "" when the
+// VM has no networks.
+func (v *VM) NetworkConfig() (string, error) {
+	if len(v.Networks) == 0 {
+		return "", nil
+	}
+	type nameservers struct {
+		Addresses []string `yaml:"addresses"`
+	}
+	type ethernet struct {
+		Match       map[string]string `yaml:"match"`
+		DHCP4       bool              `yaml:"dhcp4"`
+		Addresses   []string          `yaml:"addresses,omitempty"`
+		Gateway4    string            `yaml:"gateway4,omitempty"`
+		Nameservers *nameservers      `yaml:"nameservers,omitempty"`
+	}
+	ethernets := map[string]ethernet{}
+	for i, n := range v.Networks {
+		e := ethernet{Match: map[string]string{}, DHCP4: n.IP == ""}
+		if n.MAC != "" {
+			e.Match["macaddress"] = n.MAC
+		} else {
+			// Validate requires a MAC when the VM has several interfaces.
+			e.Match["name"] = "e*"
+		}
+		if n.IP != "" {
+			e.Addresses = []string{n.IP}
+			e.Gateway4 = n.Gateway
+		}
+		if len(n.Nameservers) > 0 {
+			e.Nameservers = &nameservers{Addresses: n.Nameservers}
+		}
+		ethernets[fmt.Sprintf("nic%d", i)] = e
+	}
+	return marshalYAML(map[string]any{"version": 2, "ethernets": ethernets})
+}
+
+// marshalYAML encodes v with the two-space indent cloud-init examples use.
+func marshalYAML(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// agentDisk, agentNetwork, agentCloudInit and agentVM mirror the
+// compute-agent VMSpec JSON (snake_case proto field names).
+type agentDisk struct {
+	Path   string `json:"path"`
+	Device string `json:"device"`
+	Format string `json:"format"`
+	SizeGB int64  `json:"size_gb,omitempty"`
+	Type   string `json:"type"`
+	Boot   bool   `json:"boot,omitempty"`
+}
+
+type agentNetwork struct {
+	Network    string `json:"network"`
+	MacAddress string `json:"mac_address,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+}
+
+type agentCloudInit struct {
+	UserData      string `json:"user_data"`
+	MetaData      string `json:"meta_data"`
+	NetworkConfig string `json:"network_config,omitempty"`
+}
+
+type agentVM struct {
+	Name            string            `json:"name"`
+	Vcpus           int32             `json:"vcpus"`
+	MemoryMB        int64             `json:"memory_mb"`
+	Disks           []agentDisk       `json:"disks"`
+	Networks        []agentNetwork    `json:"networks,omitempty"`
+	CloudInitConfig agentCloudInit    `json:"cloud_init_config"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// AgentSpec normalizes and validates v and renders it as a compute-agent
+// VMSpec JSON document, the format `workload schedule --spec-file` accepts
+// for --type vm.
+func (v *VM) AgentSpec(instanceID string) ([]byte, error) {
+	v.Normalize()
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	userData, err := v.UserData()
+	if err != nil {
+		return nil, fmt.Errorf("render user data: %w", err)
+	}
+	metaData, err := v.MetaData(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("render meta data: %w", err)
+	}
+	networkConfig, err := v.NetworkConfig()
+	if err != nil {
+		return nil, fmt.Errorf("render network config: %w", err)
+	}
+
+	spec := agentVM{
+		Name:     v.Name,
+		Vcpus:    v.Vcpus,
+		MemoryMB: v.MemoryMB,
+		CloudInitConfig: agentCloudInit{
+			UserData:      userData,
+			MetaData:      metaData,
+			NetworkConfig: networkConfig,
+		},
+		Metadata: v.Metadata,
+	}
+	for _, d := range v.Disks {
+		spec.Disks = append(spec.Disks, agentDisk{
+			Path:   d.Path,
+			Device: d.Device,
+			Format: d.Format,
+			SizeGB: d.SizeGB,
+			Type:   d.Type,
+			Boot:   d.Boot,
+		})
+	}
+	for _, n := range v.Networks {
+		ip := ""
+		if n.IP != "" {
+			addr, _, _ := net.ParseCIDR(n.IP)
+			ip = addr.String()
+		}
+		spec.Networks = append(spec.Networks, agentNetwork{Network: n.Network, MacAddress: n.MAC, IPAddress: ip})
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
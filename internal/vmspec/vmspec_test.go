@@ -0,0 +1,102 @@
+package vmspec_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/persys-dev/persysctl/internal/vmspec"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAgentSpecRendersCloudInit(t *testing.T) {
+	vm := &vmspec.VM{
+		Name:     "Web-VM",
+		Vcpus:    2,
+		MemoryMB: 2048,
+		Disks: []vmspec.Disk{
+			{Path: "/images/ubuntu.qcow2", SizeGB: 20, Boot: true},
+			{Path: "/images/seed.iso", Format: "iso"},
+		},
+		Networks: []vmspec.Network{{Network: "default", MAC: "52:54:00:AB:CD:EF", IP: "10.0.0.10/24", Gateway: "10.0.0.1"}},
+		Users:    []vmspec.User{{Name: "ops", Groups: []string{"sudo"}, SSHAuthorizedKeys: []string{"ssh-ed25519 AAAA ops"}}},
+		Packages: []string{"qemu-guest-agent"},
+		Runcmd:   []string{"echo 'done: \"ok\"' > /tmp/done"},
+	}
+	data, err := vm.AgentSpec("web-vm")
+	if err != nil {
+		t.Fatalf("AgentSpec: %v", err)
+	}
+
+	var spec struct {
+		Disks []struct {
+			Device, Format, Type string
+		} `json:"disks"`
+		Networks []struct {
+			MacAddress string `json:"mac_address"`
+			IPAddress  string `json:"ip_address"`
+		} `json:"networks"`
+		CloudInit     *string `json:"cloud_init"`
+		CloudInitConf struct {
+			UserData      string `json:"user_data"`
+			MetaData      string `json:"meta_data"`
+			NetworkConfig string `json:"network_config"`
+		} `json:"cloud_init_config"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if spec.CloudInit != nil {
+		t.Fatalf("legacy cloud_init must not be emitted")
+	}
+	if d := spec.Disks[1]; d.Device != "sda" || d.Type != "cdrom" || spec.Disks[0].Device != "vda" || spec.Disks[0].Format != "qcow2" {
+		t.Fatalf("disk defaults not applied: %+v", spec.Disks)
+	}
+	if n := spec.Networks[0]; n.MacAddress != "52:54:00:ab:cd:ef" || n.IPAddress != "10.0.0.10" {
+		t.Fatalf("network = %+v", n)
+	}
+
+	userData := spec.CloudInitConf.UserData
+	if !strings.HasPrefix(userData, "#cloud-config\n") {
+		t.Fatalf("user data lacks #cloud-config header:\n%s", userData)
+	}
+	var cloudConfig map[string]any
+	if err := yaml.Unmarshal([]byte(userData), &cloudConfig); err != nil {
+		t.Fatalf("user data is not valid YAML: %v", err)
+	}
+	if cloudConfig["hostname"] != "web-vm" || cloudConfig["runcmd"].([]any)[0] != vm.Runcmd[0] {
+		t.Fatalf("unexpected cloud-config: %v", cloudConfig)
+	}
+	if !strings.Contains(spec.CloudInitConf.MetaData, `"instance-id":"web-vm"`) {
+		t.Fatalf("meta data = %s", spec.CloudInitConf.MetaData)
+	}
+	if !strings.Contains(spec.CloudInitConf.NetworkConfig, "macaddress: 52:54:00:ab:cd:ef") {
+		t.Fatalf("network config does not match the MAC:\n%s", spec.CloudInitConf.NetworkConfig)
+	}
+}
+
+func TestValidateRejectsBadSpecs(t *testing.T) {
+	base := func() *vmspec.VM {
+		return &vmspec.VM{Name: "vm", Vcpus: 1, MemoryMB: 512, Disks: []vmspec.Disk{{Path: "/img.qcow2"}}}
+	}
+	cases := map[string]func(*vmspec.VM){
+		"multicast mac": func(v *vmspec.VM) { v.Networks = []vmspec.Network{{Network: "default", MAC: "01:00:5e:00:00:01"}} },
+		"short mac":     func(v *vmspec.VM) { v.Networks = []vmspec.Network{{Network: "default", MAC: "52:54:00:12:34"}} },
+		"disk format":   func(v *vmspec.VM) { v.Disks[0].Format = "vmdk" },
+		"iso as disk":   func(v *vmspec.VM) { v.Disks[0].Format, v.Disks[0].Type = "iso", "disk" },
+		"ip not cidr":   func(v *vmspec.VM) { v.Networks = []vmspec.Network{{Network: "default", IP: "10.0.0.1"}} },
+		"two boot disks": func(v *vmspec.VM) {
+			v.Disks = []vmspec.Disk{{Path: "/a", Boot: true}, {Path: "/b", Boot: true}}
+		},
+	}
+	for name, mutate := range cases {
+		vm := base()
+		mutate(vm)
+		if _, err := vm.AgentSpec("vm"); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	if _, err := base().AgentSpec("vm"); err != nil {
+		t.Fatalf("valid spec rejected: %v", err)
+	}
+}